@@ -5,6 +5,9 @@ import (
 	"embed"
 	"log"
 	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wailsapp/wails/v2"
@@ -25,11 +28,16 @@ var assets embed.FS
 const DefaultWindowStartState = options.Normal
 
 type App struct {
-	ctx context.Context
+	ctx      context.Context
+	web      *features.Frontend
+	args     []string
+	instance *platform.Instance
 }
 
-func NewApp() *App {
-	return &App{}
+// NewApp returns the app opening args. instance receives the arguments
+// of later launches; it may be nil.
+func NewApp(args []string, instance *platform.Instance) *App {
+	return &App{web: features.NewFrontend(), args: args, instance: instance}
 }
 
 type Recovery func(w http.ResponseWriter, r *http.Request)
@@ -47,7 +55,7 @@ func (a *App) Run(ctx context.Context) error {
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
 
-	fs := []features.Feature{a, features.NewBase(), features.NewCalc()}
+	fs := []features.Feature{a, features.NewBase(), features.NewCalc(), features.NewSettings(), features.NewLogs(), features.NewWebLog()}
 	bindings := make([]interface{}, len(fs))
 	for i, d := range fs {
 		d.Routes(ctx, handler)
@@ -70,14 +78,41 @@ func (a *App) Run(ctx context.Context) error {
 			},
 		},
 		OnStartup: func(ctx context.Context) {
+			// wails calls it in a goroutine
+			defer platform.Recover()
+			ctx = features.WithFrontend(ctx, a.web)
+			a.web.OnStartup(ctx)
 			for _, f := range fs {
-				f.OnStartup(ctx)
+				f.OnStartup(zlog.WithComponent(ctx, zlog.Named(componentName(f))))
+			}
+			openArgs := func(args []string) {
+				for _, f := range fs {
+					if o, ok := f.(features.Opener); ok {
+						o.OpenArgs(args)
+					}
+				}
+			}
+			// scanning the directories passed may take a while
+			platform.Go(func() { openArgs(a.args) })
+			if a.instance != nil {
+				platform.Go(func() {
+					a.instance.Serve(ctx, func(args []string) {
+						openArgs(args)
+						runtime.WindowUnminimise(ctx)
+						runtime.WindowShow(ctx)
+					})
+				})
 			}
 		},
 		OnShutdown: func(ctx context.Context) {
 			for _, f := range fs {
 				f.OnShutdown(ctx)
 			}
+			a.web.OnShutdown(ctx)
+		},
+		DragAndDrop: &options.DragAndDrop{
+			EnableFileDrop:     true,
+			DisableWebViewDrop: true,
 		},
 		Windows: &windows.Options{
 			WebviewUserDataPath: platform.UserDataPath(),
@@ -85,19 +120,61 @@ func (a *App) Run(ctx context.Context) error {
 	})
 }
 
+// componentName names the logger of a feature after its type, e.g.
+// "base" for *features.Base.
+func componentName(f features.Feature) string {
+	t := reflect.TypeOf(f)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.ToLower(t.Name())
+}
+
 func (a *App) OnStartup(ctx context.Context) {
+	a.ctx = ctx
+	log := zlog.FromContext(ctx)
 	runtime.EventsOn(ctx, "openExplorer", func(args ...interface{}) {
+		defer platform.Recover()
 		if len(args) > 0 {
 			if target, ok := args[0].(string); ok {
 				if err := platform.OpenFileExplorer(target); err != nil {
-					zlog.Error("openExplorer: ", err)
+					log.Error("openExplorer: ", err)
 				}
 			}
 		}
 	})
-	runtime.EventsOnce(ctx, "webReady", func(_ ...interface{}) {
-		runtime.EventsEmit(ctx, "isMaximized", DefaultWindowStartState == options.Maximised)
+	a.web.OnReady(func() {
+		a.web.Emit("isMaximized", runtime.WindowIsMaximised(ctx))
 	})
+	a.offerCrashReport(log)
+}
+
+// CrashReportNotice is the data of the "crashReport" event.
+type CrashReportNotice struct {
+	Path string `json:"path"`
+	// CanShow tells whether ShowCrashReport can open the file explorer,
+	// otherwise only the path is shown.
+	CanShow bool `json:"canShow"`
+}
+
+// offerCrashReport tells the web side about the newest crash report
+// written since the last launch, once.
+func (a *App) offerCrashReport(log *zlog.Component) {
+	reports, err := platform.PendingCrashReports()
+	if err != nil {
+		log.Warn("crash reports: ", err)
+		return
+	}
+	if len(reports) == 0 {
+		return
+	}
+	log.Info("crash report: ", reports[0])
+	a.web.Emit("crashReport", CrashReportNotice{Path: reports[0], CanShow: platform.CanOpenFileExplorer()})
+	for _, r := range reports {
+		if err := platform.AckCrashReport(r); err != nil {
+			log.Warn("crash reports: ", err)
+		}
+	}
 }
 
 func (a *App) OnShutdown(ctx context.Context) {
@@ -109,5 +186,25 @@ func (a *App) Routes(ctx context.Context, e *gin.Engine) {
 }
 
 func (a *App) UserDataPath() string {
+	defer platform.Recover()
 	return platform.UserDataPath()
 }
+
+// ShowCrashReport opens the crash report name in the file explorer.
+func (a *App) ShowCrashReport(name string) error {
+	defer platform.Recover()
+	return platform.OpenFileExplorer(filepath.Join(platform.CrashDir(), filepath.Base(name)))
+}
+
+// LogLevels returns the log level of each component.
+func (a *App) LogLevels() map[string]string {
+	defer platform.Recover()
+	return zlog.Levels()
+}
+
+// SetLogLevel changes the log level of a component until the next start
+// or config reload. An empty level makes it follow the default level.
+func (a *App) SetLogLevel(component, level string) error {
+	defer platform.Recover()
+	return zlog.SetComponentLevel(component, level)
+}
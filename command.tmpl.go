@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"{{.ProjectName}}/config"
+	"{{.ProjectName}}/platform"
+)
+
+// commandLine holds the flags of main and, once parsed, of the
+// configuration. It is separate from flag.CommandLine, which wails may
+// use for its own arguments.
+var commandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
+var (
+	printConfig    = commandLine.Bool("print-config", false, "Print the effective configuration with the source of each value and exit")
+	printSchema    = commandLine.Bool("print-schema", false, "Print the JSON Schema of the configuration and exit")
+	validateConfig = commandLine.String("validate-config", "", "Validate the config `file` and exit, with status 1 if it is invalid")
+	initConfig     = commandLine.Bool("init-config", false, "Write a commented default config file into the user data directory and exit")
+	encryptSecret  = commandLine.Bool("encrypt-secret", false, "Encrypt a secret read from stdin for the config file and exit")
+)
+
+// runCommand runs the command selected by the flags instead of the app.
+// parseErr is the result of config.Parse.
+func runCommand(parseErr error) (code int, ok bool) {
+	var ferr *config.FlagError
+	switch {
+	default:
+		return 0, false
+	case errors.As(parseErr, &ferr):
+		if errors.Is(ferr, flag.ErrHelp) {
+			return 0, true
+		}
+		return 2, true
+	case *printSchema:
+		schema, err := config.Schema()
+		if err != nil {
+			return fail(err), true
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(schema); err != nil {
+			return fail(err), true
+		}
+	case *validateConfig != "":
+		if err := config.ValidateFile(*validateConfig); err != nil {
+			return fail(err), true
+		}
+		fmt.Println(*validateConfig + ": ok")
+	case *printConfig:
+		if parseErr != nil {
+			return fail(parseErr), true
+		}
+		if err := config.Print(os.Stdout); err != nil {
+			return fail(err), true
+		}
+	case *initConfig:
+		filename := filepath.Join(platform.UserDataPath(), "config.yaml")
+		if err := config.WriteDefault(filename); err != nil {
+			return fail(err), true
+		}
+		fmt.Println(filename)
+	case *encryptSecret:
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fail(err), true
+		}
+		s, err := config.EncryptSecret(strings.TrimRight(string(b), "\r\n"))
+		if err != nil {
+			return fail(err), true
+		}
+		fmt.Println(s)
+	}
+	return 0, true
+}
+
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, err)
+	return 1
+}
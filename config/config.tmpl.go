@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"{{.ProjectName}}/platform"
 )
@@ -10,10 +11,84 @@ import (
 var (
 	ConfigPath = filepath.Join(platform.UserDataPath(), "config.json")
 	Config     Configuration
+
+	// SystemConfigPath is the system-wide config file, loaded before
+	// ConfigPath.
+	SystemConfigPath = filepath.Join(systemConfigDir(), platform.ProjectName, "config.json")
+
+	// LocalConfigPath is the project-local config file, relative to the
+	// working directory, loaded after ConfigPath.
+	LocalConfigPath = platform.ProjectName + ".config.json"
+
+	// SecretKeyPath is the local key of the encrypted secrets.
+	SecretKeyPath = filepath.Join(platform.UserDataPath(), "secret.key")
+
+	// EnvPrefix is prepended to the environment variables derived from
+	// the field keys.
+	EnvPrefix = envKey(platform.ProjectName) + "_"
 )
 
 type Configuration struct {
-	LogLevel string `json:"log_level" yaml:"log_level" default:"INFO" usage:"Log level words: trace, debug, info, warn, error, panic, fatal, disabled"`
+	LogLevel     string            `json:"log_level" yaml:"log_level" toml:"log_level" default:"info" env:"LOG_LEVEL" enum:"trace,debug,info,warn,error,panic,fatal,disabled" usage:"Log level words: trace, debug, info, warn, error, panic, fatal, disabled" settings:"true"`
+	LogLevels    map[string]string `json:"log_levels" yaml:"log_levels" toml:"log_levels" enum:"trace,debug,info,warn,error,panic,fatal,disabled" usage:"Log levels of components, e.g. exec=debug,wails=warn"`
+	LogFormat    string            `json:"log_format" yaml:"log_format" toml:"log_format" default:"console" enum:"console,json,logfmt" usage:"Format of the log file: console, json or logfmt, applied at startup"`
+	LogRotate    LogRotate         `json:"log_rotate" yaml:"log_rotate" toml:"log_rotate"`
+	LogRedact    LogRedact         `json:"log_redact" yaml:"log_redact" toml:"log_redact"`
+	LogBuffer    int               `json:"log_buffer" yaml:"log_buffer" toml:"log_buffer" default:"1024" min:"0" usage:"Log entries queued for the background writer, 0 to write synchronously, applied at startup"`
+	LogOverflow  string            `json:"log_overflow" yaml:"log_overflow" toml:"log_overflow" default:"drop" enum:"drop,block" usage:"When the log buffer is full: drop the entries or block until there is room, applied at startup"`
+	LogFileLevel string            `json:"log_file_level" yaml:"log_file_level" toml:"log_file_level" default:"trace" enum:"trace,debug,info,warn,error,panic,fatal,disabled" usage:"Minimum level written to the log file, on top of log_level and log_levels, applied at startup"`
+	LogStderr    LogStderr         `json:"log_stderr" yaml:"log_stderr" toml:"log_stderr"`
+	LogJournald  LogJournald       `json:"log_journald" yaml:"log_journald" toml:"log_journald"`
+	LogSampling  LogSampling       `json:"log_sampling" yaml:"log_sampling" toml:"log_sampling"`
+	APIToken     string            `json:"api_token" yaml:"api_token" toml:"api_token" secret:"true" usage:"API token: the value, file:/path, env:NAME, or enc:... from -encrypt-secret" settings:"true"`
+}
+
+// LogRotate configures the rotation of the log file.
+type LogRotate struct {
+	MaxSizeMB  int           `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb" default:"10" min:"0" usage:"Rotate the log when it reaches this size in MB, 0 to disable"`
+	Daily      bool          `json:"daily" yaml:"daily" toml:"daily" usage:"Rotate the log when the day changes"`
+	MaxBackups int           `json:"max_backups" yaml:"max_backups" toml:"max_backups" default:"5" min:"0" usage:"Number of rotated logs kept, 0 for all"`
+	Naming     string        `json:"naming" yaml:"naming" toml:"naming" default:"numbered" enum:"numbered,timestamp" usage:"Names of the rotated logs: numbered (log.1) or timestamp (log.2006-01-02T15-04-05.000)"`
+	Compress   bool          `json:"compress" yaml:"compress" toml:"compress" default:"true" usage:"Compress the rotated logs with gzip"`
+	MaxAge     time.Duration `json:"max_age" yaml:"max_age" toml:"max_age" min:"0s" usage:"Remove the rotated logs older than this, e.g. 720h, 0 to keep them"`
+	MaxTotalMB int           `json:"max_total_mb" yaml:"max_total_mb" toml:"max_total_mb" min:"0" usage:"Remove the oldest rotated logs while all logs exceed this size in MB, 0 to disable"`
+}
+
+// LogStderr configures the mirror of the log on stderr.
+type LogStderr struct {
+	Enabled string `json:"enabled" yaml:"enabled" toml:"enabled" default:"auto" enum:"auto,on,off" usage:"Mirror the log to stderr: auto (in debug builds), on or off, applied at startup"`
+	Level   string `json:"level" yaml:"level" toml:"level" default:"trace" enum:"trace,debug,info,warn,error,panic,fatal,disabled" usage:"Minimum level written to stderr"`
+	Format  string `json:"format" yaml:"format" toml:"format" default:"console" enum:"console,json,logfmt" usage:"Format of stderr: console, json or logfmt"`
+	Color   bool   `json:"color" yaml:"color" toml:"color" default:"true" usage:"Colorize the console format on stderr"`
+}
+
+// LogJournald configures the output of the log to systemd journald.
+type LogJournald struct {
+	Enabled bool   `json:"enabled" yaml:"enabled" toml:"enabled" usage:"Send the log to systemd journald (Linux), applied at startup"`
+	Level   string `json:"level" yaml:"level" toml:"level" default:"info" enum:"trace,debug,info,warn,error,panic,fatal,disabled" usage:"Minimum level sent to journald"`
+}
+
+// LogSampling limits the entries of loops failing repeatedly.
+type LogSampling struct {
+	Window time.Duration  `json:"window" yaml:"window" toml:"window" default:"10s" min:"0s" usage:"Collapse identical messages within this window into one entry with a repeated count, 0 to disable"`
+	Rates  map[string]int `json:"rates" yaml:"rates" toml:"rates" usage:"Maximum entries per second by level, e.g. debug=100,info=50"`
+}
+
+// LogRedact configures the values hidden from the log, besides the
+// secrets of the config.
+type LogRedact struct {
+	Home     bool     `json:"home" yaml:"home" toml:"home" usage:"Replace the home directory with ~ in the log"`
+	Patterns []string `json:"patterns" yaml:"patterns" toml:"patterns" usage:"Regular expressions of the values hidden from the log"`
+}
+
+func systemConfigDir() string {
+	if platform.IsWindows() {
+		if v := os.Getenv("ProgramData"); v != "" {
+			return v
+		}
+		return `C:\ProgramData`
+	}
+	return "/etc"
 }
 
 func init() {
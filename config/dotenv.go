@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dotenvFormat reads KEY=VALUE lines, e.g. config.env next to config.json.
+// Keys are the environment variables of the fields, e.g.
+// APP_LOG_ROTATE_DAILY=true, or the config keys, case insensitive, with
+// dots for nested values, e.g. LOG_ROTATE.DAILY=true. Other keys are
+// rejected. Values may be quoted; lists are comma separated.
+type dotenvFormat struct{}
+
+func (dotenvFormat) Decode(r io.Reader, filename string) (map[string]any, error) {
+	var c Configuration
+	items, err := fields(reflect.ValueOf(&c).Elem(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]any)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4<<10), 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		s = strings.TrimPrefix(s, "export ")
+
+		k, v, ok := strings.Cut(s, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, &SyntaxError{File: filename, Line: line, Err: errors.New("expected KEY=VALUE")}
+		}
+		path := dotenvPath(items, k)
+		if path == nil {
+			return nil, &SyntaxError{File: filename, Line: line, Err: fmt.Errorf("unknown key %s", k)}
+		}
+
+		v, err := unquoteEnv(strings.TrimSpace(v))
+		if err != nil {
+			return nil, &SyntaxError{File: filename, Line: line, Err: err}
+		}
+		setPath(config, path, v)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, &SyntaxError{File: filename, Line: line + 1, Err: err}
+	}
+	return config, nil
+}
+
+// dotenvPath returns the path in the config of the dotenv key k, or nil
+// if k names no field of items.
+func dotenvPath(items []field, k string) []string {
+	key := strings.ToLower(k)
+	if key == includeKey {
+		return []string{includeKey}
+	}
+	for _, f := range items {
+		if name := envName(f); name != "" && strings.EqualFold(name, k) || f.key == key {
+			return f.path
+		}
+		// an entry of a map, e.g. LOG_LEVELS.EXEC=debug
+		if name, ok := strings.CutPrefix(key, f.key+"."); ok && f.value.Kind() == reflect.Map {
+			return append(f.path[:len(f.path):len(f.path)], name)
+		}
+	}
+	return nil
+}
+
+func unquoteEnv(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		q, err := strconv.QuotedPrefix(v)
+		if err != nil {
+			return "", errors.New("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(v[len(q):]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", errors.New("unexpected data after quoted value")
+		}
+		return strconv.Unquote(q)
+	case strings.HasPrefix(v, "'"):
+		i := strings.IndexByte(v[1:], '\'')
+		if i < 0 {
+			return "", errors.New("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(v[i+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", errors.New("unexpected data after quoted value")
+		}
+		return v[1 : i+1], nil
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v), nil
+}
+
+func (dotenvFormat) Encode(w io.Writer, config map[string]any) error {
+	lines := make([]string, 0, len(config))
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if mm, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", mm)
+				continue
+			}
+			lines = append(lines, prefix+k+"="+quoteEnv(v))
+		}
+	}
+	flatten("", config)
+	sort.Strings(lines)
+
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		if _, err := bw.WriteString(l + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func quoteEnv(v any) string {
+	var s string
+	switch x := v.(type) {
+	default:
+		s = fmt.Sprint(x)
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(x))
+		for i := range x {
+			parts[i] = fmt.Sprint(x[i])
+		}
+		s = strings.Join(parts, ",")
+	}
+	if strings.ContainsAny(s, " \t\r\n#'\"\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+)
+
+// Entry is a value of the effective configuration.
+type Entry struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Effective returns the values of the active configuration with their
+// sources. Secrets are redacted.
+func Effective() []Entry {
+	mu.RLock()
+	c := Config
+	sources := loaded.sources
+	mu.RUnlock()
+
+	items, _ := fields(reflect.ValueOf(&c).Elem(), nil)
+	entries := make([]Entry, len(items))
+	for i, f := range items {
+		entries[i] = Entry{Key: f.key, Value: settingValue(f.value), Source: sources[f.key]}
+		if isSecret(f) && formatValue(f.value) != "" {
+			entries[i].Value = Redacted
+		}
+	}
+	return entries
+}
+
+// Print writes the effective configuration as key = value lines,
+// commented with the source of each value. Values are written in JSON.
+func Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, e := range Effective() {
+		value, err := printValue(e.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Key, err)
+		}
+		if _, err := fmt.Fprintf(tw, "%s = %s\t# %s\n", e.Key, value, e.Source); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// printValue encodes v in JSON, with empty lists and maps rather than
+// null.
+func printValue(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s), nil
+	}
+	switch rv := reflect.ValueOf(v); {
+	case rv.Kind() == reflect.Slice && rv.IsNil():
+		return "[]", nil
+	case rv.Kind() == reflect.Map && rv.IsNil():
+		return "{}", nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// ValidateFile checks the config file filename and the files it includes,
+// without changing the active configuration.
+func ValidateFile(filename string) error {
+	layers, err := readLayers(filename, nil)
+	if err != nil {
+		return err
+	}
+	if len(layers) == 0 {
+		return fmt.Errorf("%s: no config file", filename)
+	}
+	_, err = reparse("", layers)
+	return err
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyntaxError is a malformed config file.
+type SyntaxError struct {
+	File   string
+	Line   int // 1-based, 0 if unknown
+	Column int // 1-based, 0 if unknown
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// configFiles returns the existing files named like filename with any of
+// the config extensions.
+func configFiles(filename string) []string {
+	p := filepath.Clean(filename)
+	dir, name, ext := filepath.Dir(p), filepath.Base(p), filepath.Ext(p)
+	if len(name) > len(ext) {
+		name = name[:len(name)-len(ext)]
+	}
+
+	var files []string
+	for _, ext := range configExts {
+		target := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			files = append(files, target)
+		}
+	}
+	return files
+}
+
+// readConfigFile decodes the config file. It returns an empty map and no
+// path if there is none, and an error if more than one exists.
+func readConfigFile(filename string) (map[string]any, string, error) {
+	files := configFiles(filename)
+	switch len(files) {
+	case 0:
+		return make(map[string]any), "", nil
+	case 1:
+	default:
+		return nil, "", fmt.Errorf("ambiguous config files: %s", strings.Join(files, ", "))
+	}
+
+	target := files[0]
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	config, err := formats[filepath.Ext(target)].Decode(f, target)
+	if err != nil {
+		return nil, "", err
+	}
+	if config == nil {
+		config = make(map[string]any)
+	}
+	return config, target, nil
+}
+
+// position converts a byte offset of the file to a line and column.
+func position(filename string, offset int64) (line, column int) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	line, column = 1, 1
+	r := bufio.NewReader(io.LimitReader(f, offset))
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return line, column
+		}
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format reads and writes config files of one extension.
+type Format interface {
+	// Decode reads the whole config file. Syntax errors should be
+	// returned as *SyntaxError.
+	Decode(r io.Reader, filename string) (map[string]any, error)
+	Encode(w io.Writer, config map[string]any) error
+}
+
+// Updater is implemented by the formats which can change the values of
+// a config file while keeping its comments.
+type Updater interface {
+	// Update returns the content of the config file old with the values
+	// of config.
+	Update(old []byte, config map[string]any) ([]byte, error)
+}
+
+var (
+	formats    = map[string]Format{}
+	configExts []string
+)
+
+// RegisterFormat makes config files with the extension ext, e.g. ".ini",
+// readable and writable.
+func RegisterFormat(ext string, f Format) {
+	if _, exists := formats[ext]; !exists {
+		configExts = append(configExts, ext)
+	}
+	formats[ext] = f
+}
+
+func init() {
+	RegisterFormat(".json", jsonFormat{})
+	RegisterFormat(".yaml", yamlFormat{})
+	RegisterFormat(".yml", yamlFormat{})
+	RegisterFormat(".toml", tomlFormat{})
+	RegisterFormat(".env", dotenvFormat{})
+}
+
+type jsonFormat struct{}
+
+func (jsonFormat) Decode(r io.Reader, filename string) (map[string]any, error) {
+	config := make(map[string]any)
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&config); err != nil {
+		if errors.Is(err, io.EOF) {
+			return config, nil
+		}
+		var offset int64 = -1
+		var serr *json.SyntaxError
+		var terr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &serr):
+			offset = serr.Offset - 1 // the offending byte
+		case errors.As(err, &terr):
+			offset = terr.Offset
+		}
+		e := &SyntaxError{File: filename, Err: err}
+		if offset >= 0 {
+			e.Line, e.Column = position(filename, offset)
+		}
+		return nil, e
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		e := &SyntaxError{File: filename, Err: errors.New("invalid data after top-level value")}
+		e.Line, e.Column = position(filename, dec.InputOffset())
+		return nil, e
+	}
+	return config, nil
+}
+
+func (jsonFormat) Encode(w io.Writer, config map[string]any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(config)
+}
+
+type yamlFormat struct{}
+
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func (yamlFormat) Decode(r io.Reader, filename string) (map[string]any, error) {
+	config := make(map[string]any)
+	if err := yaml.NewDecoder(r).Decode(&config); err != nil {
+		if errors.Is(err, io.EOF) {
+			return config, nil
+		}
+		e := &SyntaxError{File: filename, Err: err}
+		if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Err = errors.New(err.Error()[len(m[0]):])
+		}
+		return nil, e
+	}
+	return config, nil
+}
+
+func (yamlFormat) Encode(w io.Writer, config map[string]any) error {
+	return encodeYAML(w, config)
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Update edits the nodes of the document old, so its comments and the
+// order of its keys are kept.
+func (yamlFormat) Update(old []byte, config map[string]any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(old, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// nothing to keep
+		err := encodeYAML(buf, config)
+		return buf.Bytes(), err
+	}
+	if err := updateMapping(doc.Content[0], config); err != nil {
+		return nil, err
+	}
+	err := encodeYAML(buf, &doc)
+	return buf.Bytes(), err
+}
+
+// updateMapping changes the mapping node n to the values of m. The nodes
+// of the unchanged values are left as they are.
+func updateMapping(n *yaml.Node, m map[string]any) error {
+	seen := make(map[string]bool, len(m))
+	content := n.Content[:0]
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		value, ok := m[k.Value]
+		if !ok {
+			continue
+		}
+		seen[k.Value] = true
+		if err := updateNode(v, value); err != nil {
+			return err
+		}
+		content = append(content, k, v)
+	}
+	n.Content = content
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		var v yaml.Node
+		if err := v.Encode(m[k]); err != nil {
+			return err
+		}
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, &v)
+	}
+	return nil
+}
+
+func updateNode(n *yaml.Node, value any) error {
+	if m, ok := value.(map[string]any); ok && n.Kind == yaml.MappingNode {
+		return updateMapping(n, m)
+	}
+	var cur any
+	if err := n.Decode(&cur); err == nil && reflect.DeepEqual(cur, value) {
+		return nil
+	}
+	var v yaml.Node
+	if err := v.Encode(value); err != nil {
+		return err
+	}
+	v.HeadComment, v.LineComment, v.FootComment = n.HeadComment, n.LineComment, n.FootComment
+	*n = v
+	return nil
+}
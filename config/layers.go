@@ -0,0 +1,200 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// includeKey lists the files merged beneath the file containing it, with
+// paths relative to that file.
+const includeKey = "include"
+
+// layer is a config file. Layers are merged in order, so the values of
+// later ones override the earlier ones.
+type layer struct {
+	path   string // "" for the user file which does not exist yet
+	values map[string]any
+	user   bool // the file written by Save
+}
+
+// configLayers reads the system, the user and the project-local config
+// files, each followed by the file of the profile, e.g. config.dev.yaml.
+// Included files come before the file including them.
+func configLayers(profile string) ([]layer, error) {
+	var layers []layer
+	for _, src := range []struct {
+		path string
+		user bool
+	}{
+		{SystemConfigPath, false},
+		{ConfigPath, true},
+		{LocalConfigPath, false},
+	} {
+		ls, err := readLayers(src.path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if src.user {
+			if len(ls) == 0 {
+				ls = []layer{{values: make(map[string]any)}}
+			}
+			ls[len(ls)-1].user = true
+		}
+		layers = append(layers, ls...)
+
+		if profile != "" {
+			ls, err := readLayers(profilePath(src.path, profile), nil)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, ls...)
+		}
+	}
+	return layers, nil
+}
+
+// profilePath inserts the profile before the extension of filename.
+func profilePath(filename, profile string) string {
+	ext := filepath.Ext(filename)
+	return filename[:len(filename)-len(ext)] + "." + profile + ext
+}
+
+// readLayers reads filename and the files it includes. It returns no
+// layers if the file does not exist.
+func readLayers(filename string, stack []string) ([]layer, error) {
+	m, p, err := readConfigFile(filename)
+	if err != nil || p == "" {
+		return nil, err
+	}
+	if slices.Contains(stack, p) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, p), " -> "))
+	}
+	stack = append(stack[:len(stack):len(stack)], p)
+
+	includes, err := includeList(m[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", p, includeKey, err)
+	}
+
+	var layers []layer
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(p), inc)
+		}
+		ls, err := readLayers(inc, stack)
+		if err != nil {
+			return nil, err
+		}
+		if len(ls) == 0 {
+			return nil, fmt.Errorf("%s %q: %s is not found", p, includeKey, inc)
+		}
+		layers = append(layers, ls...)
+	}
+	return append(layers, layer{path: p, values: m}), nil
+}
+
+func includeList(v any) ([]string, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{x}, nil
+	case []any:
+		items := make([]string, len(x))
+		for i := range x {
+			s, ok := x[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("[%d] is %T, should be string", i, x[i])
+			}
+			items[i] = s
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("is %T, should be string or list of strings", v)
+}
+
+// merge combines the values of the layers and records the file each
+// value comes from, by dotted key.
+func merge(layers []layer) (map[string]any, map[string]string) {
+	values := make(map[string]any)
+	origins := make(map[string]string)
+	for _, l := range layers {
+		file := l.path
+		if file == "" {
+			file = ConfigPath
+		}
+		mergeInto(values, l.values, nil, file, origins)
+	}
+	return values, origins
+}
+
+func mergeInto(dst, src map[string]any, path []string, file string, origins map[string]string) {
+	for k, v := range src {
+		if len(path) == 0 && k == includeKey {
+			continue
+		}
+		p := append(path[:len(path):len(path)], k)
+		if sm, ok := v.(map[string]any); ok {
+			dm, ok := dst[k].(map[string]any)
+			if !ok {
+				dm = make(map[string]any)
+				dst[k] = dm
+			}
+			mergeInto(dm, sm, p, file, origins)
+			continue
+		}
+		dst[k] = v
+		origins[strings.Join(p, ".")] = file
+	}
+}
+
+// origin returns the file of the value key. Maps may be merged from
+// several files, then the first of them by key is returned.
+func origin(origins map[string]string, key string) (string, bool) {
+	if file, ok := origins[key]; ok {
+		return file, true
+	}
+	var keys []string
+	for k := range origins {
+		if strings.HasPrefix(k, key+".") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return origins[keys[0]], true
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// profileOf returns the profile chosen with -profile in args, or else
+// with the environment variable <PREFIX>PROFILE.
+func profileOf(args []string) (string, error) {
+	name := os.Getenv(EnvPrefix + "PROFILE")
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		k, v, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if k != "profile" || !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			v = args[i]
+		}
+		name = v
+	}
+	if !profileName.MatchString(name) {
+		return "", errors.New("profile should only contain letters, digits, '-' and '_'")
+	}
+	return name, nil
+}
@@ -1,250 +1,315 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
 	"reflect"
-	"strconv"
 	"strings"
-	"time"
-
-	"gopkg.in/yaml.v3"
 )
 
-func Parse() error {
-	m, t := readConfigFile(ConfigPath)
-	err := parse(m, &Config, t)
-	if v, exists := os.LookupEnv("LOG_LEVEL"); exists {
-		Config.LogLevel = v
-	} else if err != nil {
-		Config.LogLevel = "info"
+// Parse loads Config from the config files, the environment and args,
+// registering a flag for each field on fs. Each field is resolved with
+// the precedence
+//
+//	default tag
+//	< system config file (SystemConfigPath)
+//	< user config file (ConfigPath)
+//	< project-local config file (LocalConfigPath)
+//	< environment variable
+//	< command line flag
+//
+// A config file may be JSON, YAML, TOML or dotenv, chosen by the
+// extension which exists: config.json, config.yaml, config.yml,
+// config.toml or config.env.
+//
+// Each config file is followed by the file of the profile chosen with
+// -profile or <PREFIX>PROFILE, e.g. config.dev.json, and preceded by the
+// files listed in its include key.
+//
+// The environment variable is named by the env tag, or else derived from
+// the field key with EnvPrefix, e.g. log.level => <PREFIX>LOG_LEVEL.
+//
+// fs should be created with flag.ContinueOnError; errors of the command
+// line are returned as *FlagError.
+func Parse(fs *flag.FlagSet, args []string) error {
+	profile, rerr := profileOf(args)
+	var layers []layer
+	if rerr == nil {
+		layers, rerr = configLayers(profile)
+	}
+	// the flags are parsed even if a file is broken, so -h and the
+	// commands of main keep working
+	s, err := load(fs, args, profile, layers)
+	store(s)
+	if rerr != nil && !errors.As(err, new(*FlagError)) {
+		return rerr
 	}
 	return err
 }
 
-var configExts = []string{".json", ".yaml", ".yml"}
+// Load returns a new T populated from its default tags, the config files
+// merged in order, the environment and args, with the precedence of
+// Parse. A flag is registered on fs for each field of T, which has to be
+// a struct.
+func Load[T any](fs *flag.FlagSet, args []string, files ...string) (*T, Sources, error) {
+	var layers []layer
+	for _, filename := range files {
+		ls, err := readLayers(filename, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		layers = append(layers, ls...)
+	}
+	values, origins := merge(layers)
 
-func readConfigFile(filename string) (map[string]any, string) {
-	p := filepath.Clean(filename)
-	dir, name, ext := filepath.Dir(p), filepath.Base(p), filepath.Ext(p)
-	if len(name) > len(ext) {
-		name = name[:len(name)-len(ext)]
+	val := new(T)
+	sources, err := parse(fs, args, values, origins, val)
+	if err != nil {
+		return nil, nil, err
 	}
+	return val, sources, nil
+}
 
-	for _, ext := range configExts {
-		target := filepath.Join(dir, name+ext)
-		f, err := os.Open(target)
-		if err != nil {
-			continue
-		}
+// FlagError is an invalid command line, or flag.ErrHelp if -h was given.
+// The flag set has already printed it with the usage.
+type FlagError struct {
+	Err error
+}
 
-		buf := make([]byte, 2<<10)
-		n, err := f.Read(buf)
-		if err != nil && !errors.Is(err, io.EOF) {
-			continue
-		}
+func (e *FlagError) Error() string { return e.Err.Error() }
 
-		config := make(map[string]any)
-		switch ext {
-		case ".json":
-			if err := json.Unmarshal(buf[:n], &config); err == nil {
-				return config, target
-			}
-		case ".yml", ".yaml":
-			if err = yaml.Unmarshal(buf[:n], &config); err == nil {
-				return config, target
+func (e *FlagError) Unwrap() error { return e.Err }
+
+// snapshot is a loaded configuration.
+type snapshot struct {
+	config  Configuration
+	sources Sources
+	profile string
+	layers  []layer
+	args    []string // the config flags set on the command line
+}
+
+// Sources maps each field key to where its value comes from: "default",
+// the path of a config file, "env NAME" or "flag -name".
+type Sources map[string]string
+
+func load(fs *flag.FlagSet, args []string, profile string, layers []layer) (snapshot, error) {
+	s := snapshot{profile: profile, layers: layers}
+	fs.String("profile", profile, "Name of the config profile, e.g. dev or prod (env "+EnvPrefix+"PROFILE)")
+	values, origins := merge(layers)
+	sources, err := parse(fs, args, values, origins, &s.config)
+	if err != nil {
+		s.config.LogLevel = "info"
+	}
+	s.sources = sources
+	// as given, so Reload resolves the secret references again
+	fs.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(*flagValue); ok {
+			if _, exists := sources[f.Name]; exists {
+				s.args = append(s.args, "-"+f.Name+"="+v.raw)
 			}
 		}
-	}
-	return make(map[string]any), ""
+	})
+	return s, err
 }
 
-func jsonTagKey(t reflect.StructTag) string {
-	f := strings.SplitN(t.Get("json"), ",", 2)
-	return strings.TrimSpace(f[0])
+// tagKey returns the key of a field from its json, yaml or toml tag.
+func tagKey(t reflect.StructTag) string {
+	for _, tag := range []string{"json", "yaml", "toml"} {
+		f := strings.SplitN(t.Get(tag), ",", 2)
+		if name := strings.TrimSpace(f[0]); name != "" {
+			return name
+		}
+	}
+	return ""
 }
 
-func parse(config map[string]any, val any, configPath string) error {
-	flagValues := map[string]any{}
-
-	t, s := reflect.TypeOf(val).Elem(), reflect.ValueOf(val).Elem()
+// field is a leaf of the configuration struct.
+type field struct {
+	key   string   // flag name, e.g. "log.level"
+	path  []string // keys in the config file
+	tag   reflect.StructTag
+	value reflect.Value
+}
 
+// fields walks the struct v and returns its leaves. Nested structs are
+// flattened into dotted keys.
+func fields(v reflect.Value, path []string) ([]field, error) {
+	var items []field
+	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		name := jsonTagKey(field.Tag)
-		usage := field.Tag.Get("usage")
-		defaultValue := field.Tag.Get("default")
-
-		switch field.Type.String() {
-		default:
-			return fmt.Errorf("Field %q type %s is not handled", name, field.Type.String())
-		case "string":
-			var v string
-			var d = defaultValue
-
-			if s, exists := config[name]; exists {
-				if x, ok := s.(string); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
-			}
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
 
-			flagValues[name] = &v
-			flag.StringVar(&v, name, d, usage)
-		case "bool":
-			var v bool
-			d, err := strconv.ParseBool(defaultValue)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
-			}
+		name := tagKey(sf.Tag)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
 
-			if s, exists := config[name]; exists {
-				if x, ok := s.(bool); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
+		p := append(path[:len(path):len(path)], name)
+		fv := v.Field(i)
+
+		if isNested(sf.Type) {
+			if sf.Type.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					fv.Set(reflect.New(sf.Type.Elem()))
 				}
+				fv = fv.Elem()
 			}
-
-			flagValues[name] = &v
-			flag.BoolVar(&v, name, d, usage)
-		case "int64":
-			var v int64
-			d, err := strconv.ParseInt(defaultValue, 0, 64)
+			sub, err := fields(fv, p)
 			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
+				return nil, err
 			}
+			items = append(items, sub...)
+			continue
+		}
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(int64); ok {
-					d = int64(x)
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
-			}
+		if !isSupported(sf.Type) {
+			return nil, fmt.Errorf("Field %q type %s is not handled", strings.Join(p, "."), sf.Type.String())
+		}
 
-			flagValues[name] = &v
-			flag.Int64Var(&v, name, d, usage)
-		case "uint64":
-			var v uint64
-			d, err := strconv.ParseUint(defaultValue, 0, 64)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
-			}
+		items = append(items, field{
+			key:   strings.Join(p, "."),
+			path:  p,
+			tag:   sf.Tag,
+			value: fv,
+		})
+	}
+	return items, nil
+}
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(uint64); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
-			}
+// envName returns the environment variable of f, or "" if it has none.
+func envName(f field) string {
+	if name, ok := f.tag.Lookup("env"); ok {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	return EnvPrefix + envKey(f.key)
+}
 
-			flagValues[name] = &v
-			flag.Uint64Var(&v, name, d, usage)
-		case "int":
-			var v int
-			d, err := strconv.Atoi(defaultValue)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
-			}
+// envKey converts s to an upper case identifier, e.g. my-app => MY_APP.
+func envKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+}
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(int); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
-			}
+// lookup returns the value at path in the nested map m.
+func lookup(m map[string]any, path []string) (any, bool) {
+	var cur any = m
+	for _, k := range path {
+		mm, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = mm[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
 
-			flagValues[name] = &v
-			flag.IntVar(&v, name, d, usage)
-		case "uint":
-			var v uint
-			dd, err := strconv.Atoi(defaultValue)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
-			}
-			d := uint(dd)
+// setPath stores value at path in the nested map m.
+func setPath(m map[string]any, path []string, value any) {
+	for _, k := range path[:len(path)-1] {
+		mm, ok := m[k].(map[string]any)
+		if !ok {
+			mm = make(map[string]any)
+			m[k] = mm
+		}
+		m = mm
+	}
+	m[path[len(path)-1]] = value
+}
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(uint); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
-			}
+func parse(fs *flag.FlagSet, args []string, config map[string]any, origins map[string]string, val any) (Sources, error) {
+	v := reflect.ValueOf(val).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config type %s is not a struct", v.Type())
+	}
+	items, err := fields(v, nil)
+	if err != nil {
+		return nil, err
+	}
 
-			flagValues[name] = &v
-			flag.UintVar(&v, name, d, usage)
-		case "float64":
-			var v float64
-			d, err := strconv.ParseFloat(defaultValue, 64)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
-			}
+	sources := make(Sources, len(items))
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(float64); ok {
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
-				}
+	// keep registering flags after an error, so that the command line is
+	// parsed anyway; the first error is returned
+	var first error
+	fail := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+
+	for _, f := range items {
+		sources[f.key] = "default"
+		if d := f.tag.Get("default"); d != "" {
+			if err := setString(f.value, d); err != nil {
+				fail(fmt.Errorf("config %q defaultValue: %w", f.key, err))
 			}
+		}
 
-			flagValues[name] = &v
-			flag.Float64Var(&v, name, d, usage)
-		case "time.Duration":
-			var v time.Duration
-			d, err := time.ParseDuration(defaultValue)
-			if err != nil {
-				return fmt.Errorf("config %q defaultValue: %w", name, err)
+		if x, exists := lookup(config, f.path); exists {
+			sources[f.key], _ = origin(origins, f.key)
+			if err := assign(f.value, x); err != nil {
+				fail(fmt.Errorf("%s %q: %w", sources[f.key], f.key, err))
 			}
+		}
 
-			if s, exists := config[name]; exists {
-				if x, ok := config[name].(string); ok {
-					x, err := time.ParseDuration(x)
-					if err != nil {
-						return fmt.Errorf("%s %q: %w", configPath, name, err)
-					}
-					d = x
-				} else {
-					return fmt.Errorf("config %q is %s, shound be %s", name, reflect.TypeOf(s).String(), field.Type.String())
+		usage := f.tag.Get("usage")
+		if name := envName(f); name != "" {
+			if x, exists := os.LookupEnv(name); exists {
+				sources[f.key] = "env " + name
+				if err := setString(f.value, x); err != nil {
+					fail(fmt.Errorf("env %s: %w", name, err))
 				}
 			}
+			usage += " (env " + name + ")"
+		}
+
+		fs.Var(&flagValue{v: f.value, secret: isSecret(f)}, f.key, usage)
+	}
 
-			flagValues[name] = &v
-			flag.DurationVar(&v, name, d, usage)
+	if err := fs.Parse(args); err != nil {
+		return sources, &FlagError{Err: err}
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		if _, exists := sources[fl.Name]; exists {
+			sources[fl.Name] = "flag -" + fl.Name
 		}
+	})
+	if first != nil {
+		return sources, first
 	}
 
-	flag.Parse()
+	if err := resolveSecrets(items); err != nil {
+		return sources, err
+	}
 
-	for i := 0; i < s.NumField(); i++ {
-		sf := s.Field(i)
-		tf := t.Field(i)
-		name := jsonTagKey(tf.Tag)
-		if v, exists := flagValues[name]; exists {
-			if sf.CanSet() {
-				v := reflect.ValueOf(v)
-				if !v.IsNil() {
-					v = v.Elem()
-					if tf.Type.String() == v.Type().String() {
-						sf.Set(v)
-					} else {
-						return fmt.Errorf("Field %q another type %s is not handled", name, tf.Type.String())
-					}
-				}
+	for _, f := range items {
+		if err := validate(f); err != nil {
+			if isSecret(f) {
+				err = errors.New("invalid secret value")
 			}
+			return sources, fmt.Errorf("%s %q: %w", sources[f.key], f.key, err)
 		}
 	}
-
-	return nil
+	return sources, nil
 }
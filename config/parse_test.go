@@ -0,0 +1,350 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testNested struct {
+	Host string `json:"host" default:"localhost" env:"-"`
+	Port int    `json:"port" default:"8080" min:"1" max:"65535" env:"TEST_PORT"`
+}
+
+type testConfig struct {
+	Level   string            `json:"level" default:"info" enum:"debug,info,warn" env:"TEST_LEVEL"`
+	Name    string            `json:"name" pattern:"^[a-z]*$" env:"-"`
+	Timeout time.Duration     `json:"timeout" default:"5s" min:"1s" env:"-"`
+	Ratio   float64           `json:"ratio" env:"-"`
+	Debug   *bool             `json:"debug" env:"-"`
+	Tags    []string          `json:"tags" env:"TEST_TAGS"`
+	Labels  map[string]string `json:"labels" env:"-"`
+	Token   string            `json:"token" secret:"true" env:"-"`
+	Server  testNested        `json:"server"`
+	Ignored string            `json:"-"`
+}
+
+func TestLoad(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name    string
+		files   map[string]string // file name => content, loaded in name order
+		env     map[string]string
+		args    []string
+		want    func(c *testConfig)
+		sources map[string]string
+		err     string
+	}{
+		{
+			name: "defaults",
+			want: func(c *testConfig) {},
+			sources: map[string]string{
+				"level":       "default",
+				"server.port": "default",
+			},
+		},
+		{
+			name:  "file",
+			files: map[string]string{"a.json": `{"level": "warn", "ratio": 1, "tags": ["x", "y"], "server": {"port": 9000}}`},
+			want: func(c *testConfig) {
+				c.Level = "warn"
+				c.Ratio = 1
+				c.Tags = []string{"x", "y"}
+				c.Server.Port = 9000
+			},
+			sources: map[string]string{
+				"level":       "a.json",
+				"server.port": "a.json",
+				"server.host": "default",
+			},
+		},
+		{
+			name: "later files override earlier ones",
+			files: map[string]string{
+				"a.json": `{"level": "warn", "name": "first"}`,
+				"b.yaml": "level: debug\nlabels:\n  k: v\n",
+			},
+			want: func(c *testConfig) {
+				c.Level = "debug"
+				c.Name = "first"
+				c.Labels = map[string]string{"k": "v"}
+			},
+			sources: map[string]string{
+				"level": "b.yaml",
+				"name":  "a.json",
+			},
+		},
+		{
+			name:  "env overrides file",
+			files: map[string]string{"a.toml": "level = \"warn\"\n"},
+			env:   map[string]string{"TEST_LEVEL": "debug", "TEST_PORT": "81", "TEST_TAGS": "a,b"},
+			want: func(c *testConfig) {
+				c.Level = "debug"
+				c.Server.Port = 81
+				c.Tags = []string{"a", "b"}
+			},
+			sources: map[string]string{
+				"level":       "env TEST_LEVEL",
+				"server.port": "env TEST_PORT",
+			},
+		},
+		{
+			name: "flags override env",
+			env:  map[string]string{"TEST_LEVEL": "debug"},
+			args: []string{"-level=warn", "-debug", "-timeout", "1m", "-labels", "a=1,b=2", "-server.host", "example.com"},
+			want: func(c *testConfig) {
+				c.Level = "warn"
+				c.Debug = boolPtr(true)
+				c.Timeout = time.Minute
+				c.Labels = map[string]string{"a": "1", "b": "2"}
+				c.Server.Host = "example.com"
+			},
+			sources: map[string]string{
+				"level":       "flag -level",
+				"timeout":     "flag -timeout",
+				"server.host": "flag -server.host",
+			},
+		},
+		{
+			name:  "secret from file",
+			files: map[string]string{"a.json": `{"token": "file:` + filepath.ToSlash(tokenFile) + `"}`},
+			want:  func(c *testConfig) { c.Token = "from-file" },
+		},
+		{
+			name: "secret from env",
+			env:  map[string]string{"TEST_TOKEN": "from-env"},
+			args: []string{"-token=env:TEST_TOKEN"},
+			want: func(c *testConfig) { c.Token = "from-env" },
+		},
+		{
+			name: "missing secret env",
+			args: []string{"-token=env:TEST_MISSING"},
+			err:  `config "token": environment variable TEST_MISSING is not set`,
+		},
+		{
+			name: "enum",
+			args: []string{"-level=trace"},
+			err:  `flag -level "level"`,
+		},
+		{
+			name:  "min",
+			files: map[string]string{"a.json": `{"server": {"port": 0}}`},
+			err:   `"server.port": 0 is less than min 1`,
+		},
+		{
+			name: "max",
+			env:  map[string]string{"TEST_PORT": "70000"},
+			err:  `env TEST_PORT "server.port": 70000 is greater than max 65535`,
+		},
+		{
+			name: "pattern",
+			args: []string{"-name=Abc"},
+			err:  `"name"`,
+		},
+		{
+			name:  "wrong type",
+			files: map[string]string{"a.json": `{"ratio": "much"}`},
+			err:   `"ratio"`,
+		},
+		{
+			name:  "non-integer",
+			files: map[string]string{"a.json": `{"server": {"port": 1.5}}`},
+			err:   `"server.port"`,
+		},
+		{
+			name: "invalid env",
+			env:  map[string]string{"TEST_PORT": "http"},
+			err:  "env TEST_PORT",
+		},
+		{
+			name:  "syntax error",
+			files: map[string]string{"a.json": `{"level": }`},
+			err:   "a.json:1:11",
+		},
+		{
+			name: "unknown flag",
+			args: []string{"-nope"},
+			err:  "flag provided but not defined: -nope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var files []string
+			for name, content := range tt.files {
+				p := filepath.Join(dir, name)
+				if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, p)
+			}
+			sort.Strings(files)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			got, sources, err := Load[testConfig](fs, tt.args, files...)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := &testConfig{
+				Level:   "info",
+				Timeout: 5 * time.Second,
+				Server:  testNested{Host: "localhost", Port: 8080},
+			}
+			tt.want(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			for key, src := range tt.sources {
+				if strings.HasSuffix(src, ".json") || strings.HasSuffix(src, ".yaml") || strings.HasSuffix(src, ".toml") {
+					src = filepath.Join(dir, src)
+				}
+				if sources[key] != src {
+					t.Errorf("source of %s = %q, want %q", key, sources[key], src)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTwice(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		got, _, err := Load[testConfig](fs, []string{"-level=debug"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Level != "debug" {
+			t.Errorf("run %d: level = %q", i, got.Level)
+		}
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	out := &strings.Builder{}
+	fs.SetOutput(out)
+	_, _, err := Load[testConfig](fs, []string{"-token=plain", "-h"})
+
+	var ferr *FlagError
+	if !errors.As(err, &ferr) || !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("error = %v, want FlagError with flag.ErrHelp", err)
+	}
+	if !strings.Contains(out.String(), "-server.port") {
+		t.Errorf("usage does not list -server.port:\n%s", out)
+	}
+	if strings.Contains(out.String(), "plain") {
+		t.Errorf("usage shows the secret:\n%s", out)
+	}
+}
+
+func TestLoadNotStruct(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, _, err := Load[string](fs, nil); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestEncryptSecret(t *testing.T) {
+	prev := SecretKeyPath
+	SecretKeyPath = filepath.Join(t.TempDir(), "secret.key")
+	t.Cleanup(func() { SecretKeyPath = prev })
+
+	enc, err := EncryptSecret("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "enc:") || strings.Contains(enc, "s3cret") {
+		t.Fatalf("EncryptSecret = %q", enc)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	got, _, err := Load[testConfig](fs, []string{"-token=" + enc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Token != "s3cret" {
+		t.Errorf("token = %q", got.Token)
+	}
+}
+
+func TestYAMLUpdateKeepsComments(t *testing.T) {
+	old := `# the level
+level: info # inline
+# unknown to the struct
+extra: 1
+server:
+  # the port
+  port: 8080
+`
+	b, err := yamlFormat{}.Update([]byte(old), map[string]any{
+		"level":  "debug",
+		"extra":  1,
+		"server": map[string]any{"port": 8080, "host": "example.com"},
+		"tags":   []any{"a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# the level
+level: debug # inline
+# unknown to the struct
+extra: 1
+server:
+  # the port
+  port: 8080
+  host: example.com
+tags:
+  - a
+`
+	if got := string(b); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDotenvKeys(t *testing.T) {
+	src := "LOG_LEVEL=debug\n" + EnvPrefix + "LOG_ROTATE_DAILY=true\nlog_levels.exec=warn\nLOG_REDACT.HOME=true\n"
+	got, err := dotenvFormat{}.Decode(strings.NewReader(src), "config.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"log_level":  "debug",
+		"log_rotate": map[string]any{"daily": "true"},
+		"log_levels": map[string]any{"exec": "warn"},
+		"log_redact": map[string]any{"home": "true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	_, err = dotenvFormat{}.Decode(strings.NewReader("# comment\nUNKNOWN=1\n"), "config.env")
+	var serr *SyntaxError
+	if !errors.As(err, &serr) || serr.Line != 2 {
+		t.Errorf("unknown key: got %v, want a syntax error on line 2", err)
+	}
+}
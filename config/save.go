@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Setting describes a Configuration field tagged with `settings:"true"`.
+type Setting struct {
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Value   any    `json:"value"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// Settings returns the fields which can be changed from the UI.
+func Settings() []Setting {
+	c := Current()
+	items, _ := fields(reflect.ValueOf(&c).Elem(), nil)
+
+	var settings []Setting
+	for _, f := range items {
+		if f.tag.Get("settings") != "true" {
+			continue
+		}
+		s := Setting{
+			Key:     f.key,
+			Type:    f.value.Type().String(),
+			Value:   settingValue(f.value),
+			Default: f.tag.Get("default"),
+			Usage:   f.tag.Get("usage"),
+		}
+		if isSecret(f) && formatValue(f.value) != "" {
+			s.Value = Redacted
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+func settingValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == durationType || v.Type().Implements(textMarshalerType) {
+		return formatValue(v)
+	}
+	return v.Interface()
+}
+
+// setMu serializes Set and Save, which read the user layer and replace
+// it.
+var setMu sync.Mutex
+
+// Set changes the setting key of the active configuration. The change is
+// kept in memory until Save is called. Plain values of secrets are
+// encrypted.
+func Set(key string, value any) error {
+	var c Configuration
+	items, err := fields(reflect.ValueOf(&c).Elem(), nil)
+	if err != nil {
+		return err
+	}
+	var path []string
+	for _, f := range items {
+		if f.key == key && f.tag.Get("settings") == "true" {
+			path = f.path
+			if s, ok := value.(string); ok && isSecret(f) && s != "" && !isSecretRef(s) {
+				if value, err = EncryptSecret(s); err != nil {
+					return err
+				}
+			}
+			break
+		}
+	}
+	if path == nil {
+		return fmt.Errorf("setting %q is not found", key)
+	}
+
+	setMu.Lock()
+	defer setMu.Unlock()
+	mu.RLock()
+	profile := loaded.profile
+	layers := slices.Clone(loaded.layers)
+	mu.RUnlock()
+
+	i := userLayer(layers)
+	if i < 0 {
+		layers = append(layers, layer{user: true})
+		i = len(layers) - 1
+	}
+	m := clone(layers[i].values)
+	setPath(m, path, value)
+	layers[i].values = m
+
+	s, err := reparse(profile, layers)
+	if err != nil {
+		return err
+	}
+	apply(s)
+	return nil
+}
+
+// clone copies the nested maps of m, so setPath does not modify the
+// values shared with the active configuration.
+func clone(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		if mm, ok := v.(map[string]any); ok {
+			v = clone(mm)
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func userLayer(layers []layer) int {
+	for i := range layers {
+		if layers[i].user {
+			return i
+		}
+	}
+	return -1
+}
+
+// Save writes the user config file in the format it was read with. Keys
+// unknown to Configuration are kept, and so are the comments of YAML
+// files; TOML and dotenv files are rewritten without theirs. Values from
+// the other files, the environment or the command line are not written
+// unless they have been Set.
+func Save() error {
+	setMu.Lock()
+	defer setMu.Unlock()
+
+	mu.RLock()
+	var m map[string]any
+	var p string
+	if i := userLayer(loaded.layers); i >= 0 {
+		m, p = loaded.layers[i].values, loaded.layers[i].path
+	}
+	mu.RUnlock()
+	if m == nil {
+		m = make(map[string]any)
+	}
+	if p == "" {
+		p = ConfigPath
+	}
+
+	format, ok := formats[filepath.Ext(p)]
+	if !ok {
+		return fmt.Errorf("%s: unsupported config format", p)
+	}
+	if u, ok := format.(Updater); ok {
+		old, err := os.ReadFile(p)
+		if err == nil {
+			b, err := u.Update(old, m)
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			return writeFileAtomic(p, b)
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := format.Encode(buf, m); err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+
+	return writeFileAtomic(p, buf.Bytes())
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
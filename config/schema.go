@@ -0,0 +1,223 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaults returns a Configuration with the default tags applied.
+func defaults() (Configuration, []field, error) {
+	var c Configuration
+	items, err := fields(reflect.ValueOf(&c).Elem(), nil)
+	if err != nil {
+		return c, nil, err
+	}
+	for _, f := range items {
+		if d := f.tag.Get("default"); d != "" {
+			if err := setString(f.value, d); err != nil {
+				return c, nil, fmt.Errorf("config %q defaultValue: %w", f.key, err)
+			}
+		}
+	}
+	return c, items, nil
+}
+
+// Schema returns a JSON Schema of Configuration generated from its tags.
+func Schema() (map[string]any, error) {
+	_, items, err := defaults()
+	if err != nil {
+		return nil, err
+	}
+
+	root := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type":    "object",
+	}
+	for _, f := range items {
+		obj := root
+		for _, k := range f.path[:len(f.path)-1] {
+			props, _ := obj["properties"].(map[string]any)
+			if props == nil {
+				props = map[string]any{}
+				obj["properties"] = props
+			}
+			next, _ := props[k].(map[string]any)
+			if next == nil {
+				next = map[string]any{"type": "object"}
+				props[k] = next
+			}
+			obj = next
+		}
+		props, _ := obj["properties"].(map[string]any)
+		if props == nil {
+			props = map[string]any{}
+			obj["properties"] = props
+		}
+		props[f.path[len(f.path)-1]] = fieldSchema(f)
+	}
+	return root, nil
+}
+
+func fieldSchema(f field) map[string]any {
+	t := f.value.Type()
+	nullable := t.Kind() == reflect.Pointer
+	if nullable {
+		t = t.Elem()
+	}
+	s := typeSchema(t)
+	if nullable {
+		s["type"] = []any{s["type"], "null"}
+	}
+
+	if usage := f.tag.Get("usage"); usage != "" {
+		s["description"] = usage
+	}
+	if f.tag.Get("default") != "" {
+		s["default"] = settingValue(f.value)
+	}
+
+	// the constraints of enum and pattern apply to the elements
+	target := s
+	switch t.Kind() {
+	case reflect.Slice:
+		target = s["items"].(map[string]any)
+	case reflect.Map:
+		target = s["additionalProperties"].(map[string]any)
+	}
+	if e, ok := f.tag.Lookup("enum"); ok {
+		var values []any
+		for _, v := range strings.Split(e, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+		target["enum"] = values
+	}
+	if p, ok := f.tag.Lookup("pattern"); ok {
+		target["pattern"] = p
+	}
+
+	var minKey, maxKey string
+	switch {
+	case t == durationType:
+		// compared as durations, which JSON Schema can't express
+	case t.Kind() == reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case t.Kind() == reflect.Slice:
+		minKey, maxKey = "minItems", "maxItems"
+	case t.Kind() == reflect.Map:
+		minKey, maxKey = "minProperties", "maxProperties"
+	default:
+		minKey, maxKey = "minimum", "maximum"
+	}
+	if v, ok := f.tag.Lookup("min"); ok && minKey != "" {
+		s[minKey] = schemaNumber(v)
+	}
+	if v, ok := f.tag.Lookup("max"); ok && maxKey != "" {
+		s[maxKey] = schemaNumber(v)
+	}
+	return s
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	return map[string]any{"type": "string"}
+}
+
+func schemaNumber(s string) any {
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	return s
+}
+
+// DefaultFile returns a YAML config file with the default values, each
+// commented with its usage.
+func DefaultFile() ([]byte, error) {
+	_, items, err := defaults()
+	if err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range items {
+		m := root
+		for _, k := range f.path[:len(f.path)-1] {
+			m = yamlChild(m, k)
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: f.path[len(f.path)-1]}
+		var comments []string
+		if usage := f.tag.Get("usage"); usage != "" {
+			comments = append(comments, usage)
+		}
+		if name := envName(f); name != "" {
+			comments = append(comments, "env: "+name)
+		}
+		key.HeadComment = strings.Join(comments, "\n")
+
+		value := &yaml.Node{}
+		if err := value.Encode(settingValue(f.value)); err != nil {
+			return nil, fmt.Errorf("config %q: %w", f.key, err)
+		}
+		m.Content = append(m.Content, key, value)
+	}
+
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlChild returns the mapping value of key in m, adding it if missing.
+func yamlChild(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	return v
+}
+
+// WriteDefault writes DefaultFile to filename, which should have a YAML
+// extension. It fails if a config file with that name already exists.
+func WriteDefault(filename string) error {
+	if files := configFiles(filename); len(files) > 0 {
+		return fmt.Errorf("%s already exists", files[0])
+	}
+	b, err := DefaultFile()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, b)
+}
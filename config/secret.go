@@ -0,0 +1,202 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the values of secret fields wherever they are shown.
+const Redacted = "[REDACTED]"
+
+// Secret fields are strings tagged with `secret:"true"`. Their value is
+// one of
+//
+//	file:/path/to/token    the content of a file, without the trailing newline
+//	env:NAME               the value of an environment variable
+//	enc:BASE64             encrypted by EncryptSecret with the key at SecretKeyPath
+//	anything else          the plain value
+const (
+	secretFile = "file:"
+	secretEnv  = "env:"
+	secretEnc  = "enc:"
+)
+
+func isSecret(f field) bool {
+	return f.tag.Get("secret") == "true"
+}
+
+func isSecretRef(s string) bool {
+	return strings.HasPrefix(s, secretFile) || strings.HasPrefix(s, secretEnv) || strings.HasPrefix(s, secretEnc)
+}
+
+// resolveSecret returns the plain value of a secret reference.
+func resolveSecret(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, secretFile):
+		b, err := os.ReadFile(strings.TrimPrefix(s, secretFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case strings.HasPrefix(s, secretEnv):
+		name := strings.TrimPrefix(s, secretEnv)
+		v, exists := os.LookupEnv(name)
+		if !exists {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(s, secretEnc):
+		return decryptSecret(strings.TrimPrefix(s, secretEnc))
+	}
+	return s, nil
+}
+
+// resolveSecrets replaces the references of the secret fields by their
+// values.
+func resolveSecrets(items []field) error {
+	for _, f := range items {
+		if !isSecret(f) {
+			continue
+		}
+		v := f.value
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("config %q: secret should be a string", f.key)
+		}
+		s, err := resolveSecret(v.String())
+		if err != nil {
+			return fmt.Errorf("config %q: %w", f.key, err)
+		}
+		v.SetString(s)
+	}
+	return nil
+}
+
+// SecretValues returns the plain values of the secret fields of the
+// active configuration, e.g. to redact them from the logs.
+func SecretValues() []string {
+	c := Current()
+	items, _ := fields(reflect.ValueOf(&c).Elem(), nil)
+	var values []string
+	for _, f := range items {
+		if !isSecret(f) {
+			continue
+		}
+		if s := formatValue(f.value); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// String formats c with the secret fields redacted, so the configuration
+// can be logged.
+func (c Configuration) String() string {
+	items, _ := fields(reflect.ValueOf(&c).Elem(), nil)
+	parts := make([]string, len(items))
+	for i, f := range items {
+		value := formatValue(f.value)
+		if isSecret(f) && value != "" {
+			value = Redacted
+		}
+		parts[i] = f.key + ":" + value
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func secretKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(SecretKeyPath)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("%s: invalid key", SecretKeyPath)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(SecretKeyPath), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(SecretKeyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return secretKey(false)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncryptSecret encrypts plaintext with the local key, which is created
+// at SecretKeyPath if missing, and returns it as an enc: value.
+func EncryptSecret(plaintext string) (string, error) {
+	key, err := secretKey(true)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	b := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretEnc + base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+func decryptSecret(s string) (string, error) {
+	b, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("encrypted secret: %w", err)
+	}
+	key, err := secretKey(false)
+	if err != nil {
+		return "", fmt.Errorf("encrypted secret: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret: too short")
+	}
+	plain, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("encrypted secret: cannot be decrypted with the local key")
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type tomlFormat struct{}
+
+func (tomlFormat) Decode(r io.Reader, filename string) (map[string]any, error) {
+	config := make(map[string]any)
+	if err := toml.NewDecoder(r).Decode(&config); err != nil {
+		e := &SyntaxError{File: filename, Err: err}
+		var derr *toml.DecodeError
+		if errors.As(err, &derr) {
+			e.Line, e.Column = derr.Position()
+		}
+		return nil, e
+	}
+	return config, nil
+}
+
+func (tomlFormat) Encode(w io.Writer, config map[string]any) error {
+	return toml.NewEncoder(w).Encode(config)
+}
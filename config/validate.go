@@ -0,0 +1,148 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate checks the value of f against its validation tags:
+//
+//	min:"1" max:"65535"    bounds of numbers and durations, or the length
+//	                       of strings, slices and maps
+//	enum:"a,b,c"           allowed values, compared case-insensitively
+//	pattern:"^[a-z]+$"     regular expression the value has to match
+//
+// enum and pattern are applied to every element of slices and maps.
+func validate(f field) error {
+	v := f.value
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if s, ok := f.tag.Lookup("min"); ok {
+		c, err := compare(v, s)
+		if err != nil {
+			return fmt.Errorf("min tag: %w", err)
+		}
+		if c < 0 {
+			return fmt.Errorf("%s is less than min %s", describe(v), s)
+		}
+	}
+
+	if s, ok := f.tag.Lookup("max"); ok {
+		c, err := compare(v, s)
+		if err != nil {
+			return fmt.Errorf("max tag: %w", err)
+		}
+		if c > 0 {
+			return fmt.Errorf("%s is greater than max %s", describe(v), s)
+		}
+	}
+
+	if s, ok := f.tag.Lookup("enum"); ok {
+		allowed := strings.Split(s, ",")
+		for _, e := range elements(v) {
+			x := formatValue(e)
+			found := false
+			for _, a := range allowed {
+				if strings.EqualFold(x, strings.TrimSpace(a)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("%q is not one of %s", x, s)
+			}
+		}
+	}
+
+	if s, ok := f.tag.Lookup("pattern"); ok {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("pattern tag: %w", err)
+		}
+		for _, e := range elements(v) {
+			if x := formatValue(e); !re.MatchString(x) {
+				return fmt.Errorf("%q does not match %s", x, s)
+			}
+		}
+	}
+
+	return nil
+}
+
+// compare returns -1, 0 or 1 as v is less than, equal to or greater than
+// the bound s.
+func compare(v reflect.Value, s string) (int, error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Int(), int64(d)), nil
+	}
+
+	switch v.Kind() {
+	default:
+		return 0, fmt.Errorf("type %s is not handled", v.Type().String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Int(), n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Uint(), n), nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Float(), n), nil
+	case reflect.String, reflect.Slice, reflect.Map:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Len(), n), nil
+	}
+}
+
+func describe(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return "length " + strconv.Itoa(v.Len())
+	}
+	return formatValue(v)
+}
+
+func elements(v reflect.Value) []reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]reflect.Value, v.Len())
+		for i := range items {
+			items[i] = v.Index(i)
+		}
+		return items
+	case reflect.Map:
+		items := make([]reflect.Value, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items = append(items, iter.Value())
+		}
+		return items
+	}
+	return []reflect.Value{v}
+}
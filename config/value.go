@@ -0,0 +1,400 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isNested reports whether t is a struct which is walked into instead of
+// being parsed as a single value.
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func isScalar(t reflect.Type) bool {
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isSupported reports whether a field of type t can be parsed.
+func isSupported(t reflect.Type) bool {
+	switch {
+	case isScalar(t):
+		return true
+	case t.Kind() == reflect.Pointer:
+		return isScalar(t.Elem())
+	case t.Kind() == reflect.Slice:
+		return isScalar(t.Elem())
+	case t.Kind() == reflect.Map:
+		return t.Key().Kind() == reflect.String && isScalar(t.Elem())
+	}
+	return false
+}
+
+func textUnmarshaler(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if v.Kind() == reflect.Pointer || !v.CanAddr() {
+		return nil, false
+	}
+	u, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return u, ok
+}
+
+// setString parses s into v. Slices are comma separated and maps are
+// comma separated key=value pairs.
+func setString(v reflect.Value, s string) error {
+	if u, ok := textUnmarshaler(v); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	default:
+		return fmt.Errorf("type %s is not handled", v.Type().String())
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.Slice:
+		var parts []string
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		items := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setString(items.Index(i), strings.TrimSpace(p)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		v.Set(items)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, p := range strings.Split(s, ",") {
+			if strings.TrimSpace(p) == "" {
+				continue
+			}
+			k, x, ok := strings.Cut(p, "=")
+			if !ok {
+				return fmt.Errorf("%q should be key=value", p)
+			}
+			k = strings.TrimSpace(k)
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setString(e, strings.TrimSpace(x)); err != nil {
+				return fmt.Errorf("[%s]: %w", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), e)
+		}
+		v.Set(m)
+	}
+	return nil
+}
+
+// assign stores a value decoded from a config file into v.
+func assign(v reflect.Value, raw any) error {
+	if raw == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if u, ok := textUnmarshaler(v); ok {
+		s, ok := raw.(string)
+		if !ok {
+			return mismatch(v, raw)
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		s, ok := raw.(string)
+		if !ok {
+			return mismatch(v, raw)
+		}
+		return setString(v, s)
+	}
+
+	if s, ok := raw.(string); ok && (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) {
+		return setString(v, s)
+	}
+
+	switch v.Kind() {
+	default:
+		return coerce(v, raw)
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := assign(p.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			return mismatch(v, raw)
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, x := range items {
+			if err := assign(s.Index(i), x); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		items, ok := raw.(map[string]any)
+		if !ok {
+			return mismatch(v, raw)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items))
+		for k, x := range items {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := assign(e, x); err != nil {
+				return fmt.Errorf("[%s]: %w", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), e)
+		}
+		v.Set(m)
+	}
+	return nil
+}
+
+// coerce converts the scalar raw to the kind of v. JSON numbers decode
+// as json.Number or float64 and YAML integers as int, so numbers are
+// converted between kinds, with overflow and sign checks instead of
+// wrapping around. Strings are parsed like the command line values.
+func coerce(v reflect.Value, raw any) error {
+	if n, ok := raw.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return coerce(v, i)
+		}
+		if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+			return coerce(v, u)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		return coerce(v, f)
+	}
+
+	x := reflect.ValueOf(raw)
+	if x.Kind() == reflect.String && v.Kind() != reflect.String {
+		return setString(v, x.String())
+	}
+
+	switch v.Kind() {
+	default:
+		if x.Kind() != v.Kind() {
+			return mismatch(v, raw)
+		}
+		v.Set(x.Convert(v.Type()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch x.Kind() {
+		default:
+			return mismatch(v, raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = x.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if x.Uint() > math.MaxInt64 {
+				return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+			}
+			n = int64(x.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := x.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("%v is not an integer", raw)
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+			}
+			n = int64(f)
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch x.Kind() {
+		default:
+			return mismatch(v, raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if x.Int() < 0 {
+				return fmt.Errorf("%v is negative, %s is unsigned", raw, v.Type().String())
+			}
+			n = uint64(x.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = x.Uint()
+		case reflect.Float32, reflect.Float64:
+			f := x.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("%v is not an integer", raw)
+			}
+			if f < 0 {
+				return fmt.Errorf("%v is negative, %s is unsigned", raw, v.Type().String())
+			}
+			if f >= math.MaxUint64 {
+				return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+			}
+			n = uint64(f)
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch x.Kind() {
+		default:
+			return mismatch(v, raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(x.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(x.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = x.Float()
+		}
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %s", raw, v.Type().String())
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
+
+func mismatch(v reflect.Value, raw any) error {
+	return fmt.Errorf("is %s, should be %s", reflect.TypeOf(raw).String(), v.Type().String())
+}
+
+// formatValue is the inverse of setString.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+
+	switch v.Kind() {
+	default:
+		return fmt.Sprint(v.Interface())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, fmt.Sprint(iter.Key().Interface())+"="+formatValue(iter.Value()))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	}
+}
+
+// flagValue exposes a configuration field as a command line flag.
+type flagValue struct {
+	v      reflect.Value
+	secret bool
+	raw    string // as given, e.g. a secret reference before it is resolved
+}
+
+func (f *flagValue) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	s := formatValue(f.v)
+	if f.secret && s != "" {
+		return Redacted
+	}
+	return s
+}
+
+func (f *flagValue) Get() any {
+	return formatValue(f.v)
+}
+
+func (f *flagValue) Set(s string) error {
+	f.raw = s
+	return setString(f.v, s)
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	if !f.v.IsValid() {
+		return false
+	}
+	t := f.v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// Subscriber is called with the previous and the current configuration
+// after a reload has changed any value.
+type Subscriber func(prev, cur Configuration)
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[int]Subscriber{}
+	nextID      int
+
+	loaded snapshot
+)
+
+// Current returns a copy of the active configuration. Prefer it over
+// reading Config directly once Watch is running.
+func Current() Configuration {
+	mu.RLock()
+	defer mu.RUnlock()
+	return Config
+}
+
+func store(s snapshot) {
+	mu.Lock()
+	Config = s.config
+	loaded = s
+	mu.Unlock()
+}
+
+// Subscribe registers fn to be notified about configuration changes.
+// The returned function removes the subscription.
+func Subscribe(fn Subscriber) (unsubscribe func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	subscribers[id] = fn
+	return func() {
+		mu.Lock()
+		delete(subscribers, id)
+		mu.Unlock()
+	}
+}
+
+// Reload parses the config files again. The active configuration is
+// kept if the new one cannot be parsed or does not validate.
+func Reload() error {
+	mu.RLock()
+	profile := loaded.profile
+	mu.RUnlock()
+
+	layers, err := configLayers(profile)
+	if err != nil {
+		return err
+	}
+	s, err := reparse(profile, layers)
+	if err != nil {
+		return err
+	}
+	apply(s)
+	return nil
+}
+
+// reparse builds and validates a configuration from layers with a
+// private flag set, so the command line keeps its precedence.
+func reparse(profile string, layers []layer) (snapshot, error) {
+	mu.RLock()
+	args := loaded.args
+	mu.RUnlock()
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return load(fs, args, profile, layers)
+}
+
+// apply makes s the active configuration and notifies the subscribers
+// when any value has changed.
+func apply(s snapshot) {
+	mu.Lock()
+	prev, c := Config, s.config
+	loaded = s
+	if reflect.DeepEqual(prev, c) {
+		mu.Unlock()
+		return
+	}
+	Config = c
+	keys := make([]int, 0, len(subscribers))
+	for id := range subscribers {
+		keys = append(keys, id)
+	}
+	slices.Sort(keys)
+	subs := make([]Subscriber, len(keys))
+	for i, id := range keys {
+		subs[i] = subscribers[id]
+	}
+	mu.Unlock()
+
+	for _, fn := range subs {
+		fn(prev, c)
+	}
+}
+
+// Watch polls the config files every interval and reloads them when any
+// has been created, modified or removed, until ctx is done.
+func Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := fingerprint(watched())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cur := fingerprint(watched())
+			if cur == last {
+				continue
+			}
+			last = cur
+			if err := Reload(); err != nil {
+				zlog.Warn("config reload: ", err)
+			} else {
+				zlog.Info("config reloaded")
+			}
+		}
+	}
+}
+
+// watched returns the names of the config files which may be loaded.
+func watched() []string {
+	mu.RLock()
+	profile := loaded.profile
+	layers := loaded.layers
+	mu.RUnlock()
+
+	var names []string
+	for _, name := range []string{SystemConfigPath, ConfigPath, LocalConfigPath} {
+		names = append(names, name)
+		if profile != "" {
+			names = append(names, profilePath(name, profile))
+		}
+	}
+	for _, l := range layers {
+		if l.path != "" {
+			names = append(names, l.path)
+		}
+	}
+	return names
+}
+
+func fingerprint(filenames []string) string {
+	var b []byte
+	for _, filename := range filenames {
+		p := filepath.Clean(filename)
+		dir, name, ext := filepath.Dir(p), filepath.Base(p), filepath.Ext(p)
+		if len(name) > len(ext) {
+			name = name[:len(name)-len(ext)]
+		}
+
+		for _, ext := range configExts {
+			info, err := os.Stat(filepath.Join(dir, name+ext))
+			if err != nil {
+				b = append(b, '-')
+				continue
+			}
+			b = info.ModTime().AppendFormat(b, time.RFC3339Nano)
+			b = append(b, '/')
+			b = strconv.AppendInt(b, info.Size(), 10)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"{{.ProjectName}}/config"
+	"{{.ProjectName}}/platform"
+	"{{.ProjectName}}/platform/errors"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// crashing lets a single goroutine report its panic; the others wait for
+// the exit.
+var crashing sync.Mutex
+
+// reportCrash is the crash handler of the panics recovered by
+// platform.Recover. It writes a crash report with the recent log lines
+// and the config, and exits.
+//
+// The panics of the goroutines of zlog, which can't use platform, and of
+// Calc, a demo, are not reported.
+func reportCrash(e any) {
+	crashing.Lock()
+	err := errors.WrapRecoveredError(e)
+	zlog.Error("panic: ", err)
+	zlog.Flush()
+
+	name, werr := platform.WriteCrashReport(platform.CrashReport{
+		Time:   time.Now(),
+		Error:  err.Error(),
+		Stack:  err.Stack() + "\n" + string(debug.Stack()),
+		Logs:   zlog.RecentLines(),
+		Config: zlog.Redact(config.Current().String()),
+	})
+	fmt.Fprintln(os.Stderr, "panic:", err.String())
+	if werr != nil {
+		fmt.Fprintln(os.Stderr, "crash report:", werr)
+	} else {
+		fmt.Fprintln(os.Stderr, "crash report:", name)
+	}
+	os.Exit(2)
+}
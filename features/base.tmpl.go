@@ -0,0 +1,251 @@
+package features
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/evanoberholster/imagemeta"
+	"github.com/evanoberholster/imagemeta/imagetype"
+	"github.com/gin-gonic/gin"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"{{.ProjectName}}/platform"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// Feature: Preview Image
+type Base struct {
+	ctx              context.Context
+	log              *zlog.Component
+	web              *Frontend
+	mu               sync.Mutex // guards the preview below
+	currentDirectory string
+	currentFile      string
+	images           interface{}
+	catalog          catalog
+}
+
+func NewBase() Feature {
+	return &Base{}
+}
+
+func (b *Base) OnStartup(ctx context.Context) {
+	b.ctx = ctx
+	b.log = zlog.FromContext(ctx)
+	b.web = FrontendFrom(ctx)
+	b.web.OnReady(func() {
+		b.mu.Lock()
+		images := b.images
+		b.mu.Unlock()
+		if images != nil {
+			b.web.Emit("images", images)
+		}
+		if entries := b.catalog.list(); len(entries) > 0 {
+			b.web.Emit("catalog", entries)
+		}
+	})
+	runtime.OnFileDrop(ctx, func(x, y int, paths []string) {
+		defer platform.Recover()
+		b.log.Debug("drop: ", strings.Join(paths, ", "))
+		if b.catalog.add(paths, "drop") > 0 {
+			b.web.Emit("catalog", b.catalog.list())
+		}
+	})
+}
+
+func (b *Base) OnShutdown(ctx context.Context) {
+	//
+}
+
+func (b *Base) Routes(ctx context.Context, e *gin.Engine) {
+	img := e.Group("/img")
+	{
+		img.GET("/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			b.mu.Lock()
+			dir := b.currentDirectory
+			b.mu.Unlock()
+			c.File(filepath.Join(dir, name))
+		})
+	}
+	e.GET("/catalog/:id", func(c *gin.Context) {
+		p, ok := b.catalog.path(c.Param("id"))
+		if !ok {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.File(p)
+	})
+}
+
+func isImage(filename string) bool {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	e, _ := imagemeta.Decode(f)
+	if err != nil {
+		return false
+	}
+	if e.ImageType == imagetype.ImageUnknown {
+		b := make([]byte, 512)
+		_, _ = f.Seek(0, io.SeekStart)
+		_, _ = f.Read(b)
+		switch {
+		case strings.HasPrefix(http.DetectContentType(b), "image/"):
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// open returns the directory, the file and the images to preview for
+// the file or the directory arg, and nil images if there are none.
+func open(arg string) (dir, file string, images interface{}) {
+	if arg == "" {
+		return
+	}
+
+	info, err := os.Stat(arg)
+	if err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		file = filepath.Base(arg)
+		return filepath.Dir(arg), file, file
+	}
+
+	items, err := ListImages(arg)
+	if err != nil {
+		return
+	}
+	if len(items) > 0 {
+		file = items[0]
+	}
+	return arg, file, items
+}
+
+// setPreview changes the previewed images and sends them to the web side.
+func (b *Base) setPreview(dir, file string, images interface{}) {
+	b.mu.Lock()
+	b.currentDirectory = dir
+	b.currentFile = file
+	b.images = images
+	b.mu.Unlock()
+	b.web.Emit("images", images)
+}
+
+// OpenArgs previews the first argument of a launch of the app, and adds
+// the images of all of them to the catalog.
+func (b *Base) OpenArgs(args []string) {
+	defer platform.Recover()
+	if len(args) == 0 {
+		return
+	}
+	b.log.Debug("open: ", strings.Join(args, ", "))
+	if dir, file, images := open(args[0]); images != nil {
+		b.setPreview(dir, file, images)
+	}
+	if b.catalog.add(args, "args") > 0 {
+		b.web.Emit("catalog", b.catalog.list())
+	}
+}
+
+// Catalog returns the images opened from the command line or dropped
+// onto the window.
+func (b *Base) Catalog() []CatalogEntry {
+	defer platform.Recover()
+	return b.catalog.list()
+}
+
+// ClearCatalog removes all the images from the catalog.
+func (b *Base) ClearCatalog() {
+	defer platform.Recover()
+	b.catalog.clear()
+	b.web.Emit("catalog", []CatalogEntry{})
+}
+
+// ListImages returns the sorted names of the images in dirname. It stops
+// after finding three of them.
+func ListImages(dirname string) ([]string, error) {
+	return scanImages(dirname, 3)
+}
+
+// AllImages returns the sorted names of all the images in dirname.
+func AllImages(dirname string) ([]string, error) {
+	return scanImages(dirname, 0)
+}
+
+// scanImages returns the sorted names of the images in dirname, at most
+// limit of them unless limit is 0.
+func scanImages(dirname string, limit int) ([]string, error) {
+	list, err := os.ReadDir(dirname)
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for _, i := range list {
+		if isImage(filepath.Join(dirname, i.Name())) {
+			items = append(items, i.Name())
+		}
+		if len(items) == limit {
+			break
+		}
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (b *Base) OpenFile() {
+	defer platform.Recover()
+	filename, err := runtime.OpenFileDialog(b.ctx, runtime.OpenDialogOptions{})
+	if err != nil {
+		b.log.Error("open file: ", err)
+		return
+	}
+
+	if filename == "" {
+		return
+	}
+
+	if !isImage(filename) {
+		return
+	}
+
+	file := filepath.Base(filename)
+	b.setPreview(filepath.Dir(filename), file, file)
+}
+
+func (b *Base) OpenDirectory() {
+	defer platform.Recover()
+	dir, err := runtime.OpenDirectoryDialog(b.ctx, runtime.OpenDialogOptions{})
+	if err != nil {
+		b.log.Error("open directory: ", err)
+		return
+	}
+
+	if dir == "" {
+		return
+	}
+
+	items, err := ListImages(dir)
+	if err != nil {
+		return
+	}
+
+	var file string
+	if len(items) > 0 {
+		file = items[0]
+	}
+	b.setPreview(dir, file, items)
+}
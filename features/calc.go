@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"io"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -37,3 +38,18 @@ func (c *Calc) MD5(value string) string {
 	}
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// MD5File returns the MD5 checksum of the file content.
+func (c *Calc) MD5File(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,132 @@
+package features
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CatalogEntry is an image of the catalog.
+type CatalogEntry struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Dir    string `json:"dir"`
+	Source string `json:"source"` // "args" or "drop"
+}
+
+// catalog collects the images opened from the command line or dropped
+// onto the window, across directories and without duplicates.
+type catalog struct {
+	mu      sync.Mutex
+	entries []CatalogEntry
+	paths   map[string]string // id => absolute path
+}
+
+// add adds the images of paths, which may be files or directories, and
+// returns the number of new entries. The directories are scanned before
+// taking the lock, so the catalog stays available meanwhile.
+func (c *catalog) add(paths []string, source string) (added int) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			if isImage(p) {
+				files = append(files, p)
+			}
+			continue
+		}
+		names, err := scanImages(p, 0)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			files = append(files, filepath.Join(p, name))
+		}
+	}
+
+	var abs []string
+	for _, f := range files {
+		if p, ok := absPath(f); ok {
+			abs = append(abs, p)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paths == nil {
+		c.paths = make(map[string]string)
+	}
+	for _, p := range abs {
+		if c.addFile(p, source) {
+			added++
+		}
+	}
+	return added
+}
+
+// absPath returns the absolute path of filename, with the symbolic links
+// resolved when possible.
+func absPath(filename string) (string, bool) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", false
+	}
+	if p, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = p
+	}
+	return abs, true
+}
+
+// addFile adds the absolute path abs. c.mu must be held.
+func (c *catalog) addFile(abs, source string) bool {
+	id := catalogID(abs)
+	if _, exists := c.paths[id]; exists {
+		return false
+	}
+	c.paths[id] = abs
+	c.entries = append(c.entries, CatalogEntry{
+		ID:     id,
+		Name:   filepath.Base(abs),
+		Dir:    filepath.Dir(abs),
+		Source: source,
+	})
+	return true
+}
+
+// catalogID identifies the file abs, ignoring the case of the path on
+// Windows.
+func catalogID(abs string) string {
+	if runtime.GOOS == "windows" {
+		abs = strings.ToLower(abs)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(abs))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func (c *catalog) list() []CatalogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CatalogEntry{}, c.entries...)
+}
+
+func (c *catalog) path(id string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.paths[id]
+	return p, ok
+}
+
+func (c *catalog) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.paths = nil
+}
@@ -11,3 +11,10 @@ type Feature interface {
 	OnStartup(context.Context)
 	OnShutdown(context.Context)
 }
+
+// Opener is a Feature which opens the command line arguments of the app,
+// and those forwarded by later launches, e.g. files opened from the file
+// manager.
+type Opener interface {
+	OpenArgs(args []string)
+}
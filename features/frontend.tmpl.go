@@ -0,0 +1,145 @@
+package features
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"{{.ProjectName}}/platform"
+)
+
+type frontendKey struct{}
+
+type event struct {
+	name string
+	data []interface{}
+}
+
+// Frontend coordinates the readiness of the webview.
+//
+// The web side emits "webReady" when it is able to receive events, and
+// "webUnload" before the page goes away (e.g. reload). Events emitted
+// through Emit while the web side is not ready are queued and flushed
+// in order on the next handshake.
+type Frontend struct {
+	mu    sync.Mutex
+	ctx   context.Context
+	ready bool
+	wait  chan struct{}
+	queue []event
+	hooks []func()
+}
+
+func NewFrontend() *Frontend {
+	return &Frontend{wait: make(chan struct{})}
+}
+
+// WithFrontend returns a copy of ctx carrying f.
+func WithFrontend(ctx context.Context, f *Frontend) context.Context {
+	return context.WithValue(ctx, frontendKey{}, f)
+}
+
+// FrontendFrom returns the Frontend stored in ctx by WithFrontend.
+func FrontendFrom(ctx context.Context) *Frontend {
+	f, _ := ctx.Value(frontendKey{}).(*Frontend)
+	return f
+}
+
+func (f *Frontend) OnStartup(ctx context.Context) {
+	f.mu.Lock()
+	f.ctx = ctx
+	f.mu.Unlock()
+	runtime.EventsOn(ctx, "webReady", func(_ ...interface{}) {
+		defer platform.Recover()
+		f.handshake()
+	})
+	runtime.EventsOn(ctx, "webUnload", func(_ ...interface{}) {
+		defer platform.Recover()
+		f.unload()
+	})
+}
+
+func (f *Frontend) OnShutdown(ctx context.Context) {
+	runtime.EventsOff(ctx, "webReady", "webUnload")
+}
+
+// Emit sends an event to the web side, or queues it until the next handshake.
+func (f *Frontend) Emit(name string, data ...interface{}) {
+	f.mu.Lock()
+	if !f.ready {
+		f.queue = append(f.queue, event{name: name, data: data})
+		f.mu.Unlock()
+		return
+	}
+	ctx := f.ctx
+	f.mu.Unlock()
+	runtime.EventsEmit(ctx, name, data...)
+}
+
+// OnReady registers fn to be called after every handshake, including the
+// ones that follow a webview reload. Use it to resend state the web side
+// has lost.
+func (f *Frontend) OnReady(fn func()) {
+	f.mu.Lock()
+	f.hooks = append(f.hooks, fn)
+	ready := f.ready
+	f.mu.Unlock()
+	if ready {
+		fn()
+	}
+}
+
+// Ready reports whether the web side has completed the handshake.
+func (f *Frontend) Ready() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ready
+}
+
+// Wait blocks until the web side is ready or ctx is done.
+func (f *Frontend) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	wait := f.wait
+	f.mu.Unlock()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Frontend) handshake() {
+	f.mu.Lock()
+	for len(f.queue) > 0 {
+		queue := f.queue
+		f.queue = nil
+		ctx := f.ctx
+		f.mu.Unlock()
+		for _, e := range queue {
+			runtime.EventsEmit(ctx, e.name, e.data...)
+		}
+		f.mu.Lock()
+	}
+	if !f.ready {
+		f.ready = true
+		close(f.wait)
+	}
+	hooks := slices.Clone(f.hooks)
+	f.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+func (f *Frontend) unload() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ready {
+		f.ready = false
+		f.wait = make(chan struct{})
+	}
+}
@@ -0,0 +1,304 @@
+package features
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"{{.ProjectName}}/platform"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+const (
+	// tailInterval is the period of the live tail batches.
+	tailInterval = 250 * time.Millisecond
+	// tailBatch is the maximum number of entries in a batch.
+	tailBatch = 200
+	// tailBuffer is the maximum number of entries waiting for the web
+	// side; older ones are dropped.
+	tailBuffer = 2000
+)
+
+// LogFile is the current log file or a rotated one.
+type LogFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// LogQuery filters the entries of a log file.
+type LogQuery struct {
+	File   string   `json:"file"`   // name from Files, the current file if empty
+	Levels []string `json:"levels"` // all levels if empty
+	Since  string   `json:"since"`  // RFC 3339, optional
+	Until  string   `json:"until"`  // RFC 3339, optional
+	Text   string   `json:"text"`   // case insensitive
+	Offset int      `json:"offset"`
+	Limit  int      `json:"limit"`
+}
+
+// LogPage is a page of the entries matching a LogQuery, the newest first.
+type LogPage struct {
+	Entries []zlog.Entry `json:"entries"`
+	Total   int          `json:"total"`
+}
+
+// LogBatch is the payload of the "logTail" event.
+type LogBatch struct {
+	Entries []zlog.Entry `json:"entries"`
+	Dropped int          `json:"dropped"` // entries dropped since the previous batch
+}
+
+// Feature: Logs
+type Logs struct {
+	ctx context.Context
+	log *zlog.Component
+	web *Frontend
+
+	mu       sync.Mutex
+	stop     context.CancelFunc
+	pending  []zlog.Entry
+	dropped  int
+	inflight bool // a batch has been sent and not acknowledged yet
+}
+
+func NewLogs() Feature {
+	return &Logs{}
+}
+
+func (l *Logs) OnStartup(ctx context.Context) {
+	l.ctx = ctx
+	l.log = zlog.FromContext(ctx)
+	l.web = FrontendFrom(ctx)
+}
+
+func (l *Logs) OnShutdown(ctx context.Context) {
+	l.Unsubscribe()
+}
+
+func (l *Logs) Routes(ctx context.Context, e *gin.Engine) {
+	//
+}
+
+// Files returns the current log file and the rotated ones, the newest
+// first.
+func (l *Logs) Files() ([]LogFile, error) {
+	defer platform.Recover()
+	names, err := zlog.Files()
+	if err != nil {
+		return nil, err
+	}
+	var files []LogFile
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		files = append(files, LogFile{Name: filepath.Base(name), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// logFile returns the path of the log file name from Files.
+func logFile(name string) (string, error) {
+	names, err := zlog.Files()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return names[0], nil
+	}
+	for _, p := range names {
+		if filepath.Base(p) == name {
+			return p, nil
+		}
+	}
+	return "", errors.New("log file " + name + " is not found")
+}
+
+// logFilter is a compiled LogQuery.
+type logFilter struct {
+	levels       []string
+	since, until time.Time
+	text         string
+}
+
+func newLogFilter(q LogQuery) (logFilter, error) {
+	f := logFilter{levels: q.Levels, text: strings.ToLower(q.Text)}
+	var err error
+	if q.Since != "" {
+		if f.since, err = time.Parse(time.RFC3339, q.Since); err != nil {
+			return f, err
+		}
+	}
+	if q.Until != "" {
+		if f.until, err = time.Parse(time.RFC3339, q.Until); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func (f logFilter) match(line string, e zlog.Entry) bool {
+	if len(f.levels) > 0 && !slices.Contains(f.levels, e.Level) {
+		return false
+	}
+	if !f.since.IsZero() || !f.until.IsZero() {
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			return false
+		}
+		if !f.since.IsZero() && t.Before(f.since) || !f.until.IsZero() && t.After(f.until) {
+			return false
+		}
+	}
+	return f.text == "" || strings.Contains(strings.ToLower(line), f.text)
+}
+
+// Query returns a page of the entries of a log file matching q, the
+// newest first.
+func (l *Logs) Query(q LogQuery) (LogPage, error) {
+	defer platform.Recover()
+	filter, err := newLogFilter(q)
+	if err != nil {
+		return LogPage{}, err
+	}
+	name, err := logFile(q.File)
+	if err != nil {
+		return LogPage{}, err
+	}
+	r, err := zlog.Open(name)
+	if err != nil {
+		return LogPage{}, err
+	}
+	defer r.Close()
+
+	var entries []zlog.Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if e := zlog.ParseLine(line); filter.match(line, e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return LogPage{}, err
+	}
+
+	slices.Reverse(entries)
+	page := LogPage{Total: len(entries), Entries: []zlog.Entry{}}
+	if q.Offset < len(entries) {
+		end := len(entries)
+		if q.Limit > 0 && q.Offset+q.Limit < end {
+			end = q.Offset + q.Limit
+		}
+		page.Entries = entries[max(q.Offset, 0):end]
+	}
+	return page, nil
+}
+
+// Subscribe starts the live tail of the current log file, replacing the
+// previous one. The entries matching q (File, Offset and Limit are
+// ignored) are sent in "logTail" events with LogBatch, at most one batch
+// at a time: the next one waits until TailAck is called.
+func (l *Logs) Subscribe(q LogQuery) error {
+	defer platform.Recover()
+	filter, err := newLogFilter(q)
+	if err != nil {
+		return err
+	}
+	name, err := logFile("")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	l.Unsubscribe()
+	ctx, cancel := context.WithCancel(l.ctx)
+	l.mu.Lock()
+	l.stop = cancel
+	l.pending, l.dropped, l.inflight = nil, 0, false
+	l.mu.Unlock()
+
+	platform.Go(func() {
+		err := zlog.Follow(ctx, name, info.Size(), tailInterval, func(line string) {
+			if e := zlog.ParseLine(line); filter.match(line, e) {
+				l.push(e)
+			}
+		})
+		if err != nil {
+			l.log.Warn("tail: ", err)
+		}
+	})
+	platform.Go(func() { l.flush(ctx) })
+	return nil
+}
+
+// Unsubscribe stops the live tail.
+func (l *Logs) Unsubscribe() {
+	defer platform.Recover()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		l.stop()
+		l.stop = nil
+	}
+}
+
+// TailAck tells that the web side has handled the last batch.
+func (l *Logs) TailAck() {
+	defer platform.Recover()
+	l.mu.Lock()
+	l.inflight = false
+	l.mu.Unlock()
+}
+
+func (l *Logs) push(e zlog.Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, e)
+	if n := len(l.pending) - tailBuffer; n > 0 {
+		l.pending = slices.Delete(l.pending, 0, n)
+		l.dropped += n
+	}
+}
+
+// flush sends the pending entries by batches while the web side keeps
+// up with them.
+func (l *Logs) flush(ctx context.Context) {
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		if l.inflight || len(l.pending) == 0 && l.dropped == 0 {
+			l.mu.Unlock()
+			continue
+		}
+		n := min(len(l.pending), tailBatch)
+		batch := LogBatch{Entries: slices.Clone(l.pending[:n]), Dropped: l.dropped}
+		l.pending = slices.Delete(l.pending, 0, n)
+		l.dropped = 0
+		l.inflight = true
+		l.mu.Unlock()
+
+		l.web.Emit("logTail", batch)
+	}
+}
@@ -0,0 +1,58 @@
+package features
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"{{.ProjectName}}/config"
+	"{{.ProjectName}}/platform"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// Feature: Settings
+type Settings struct {
+	ctx         context.Context
+	log         *zlog.Component
+	web         *Frontend
+	unsubscribe func()
+}
+
+func NewSettings() Feature {
+	return &Settings{}
+}
+
+func (s *Settings) OnStartup(ctx context.Context) {
+	s.ctx = ctx
+	s.log = zlog.FromContext(ctx)
+	s.web = FrontendFrom(ctx)
+	s.unsubscribe = config.Subscribe(func(_, _ config.Configuration) {
+		s.web.Emit("settingsChanged", config.Settings())
+	})
+}
+
+func (s *Settings) OnShutdown(ctx context.Context) {
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+}
+
+func (s *Settings) Routes(ctx context.Context, e *gin.Engine) {
+	//
+}
+
+// Get returns the settings which can be changed from the UI.
+func (s *Settings) Get() []config.Setting {
+	defer platform.Recover()
+	return config.Settings()
+}
+
+// Set changes a setting and writes it to the config file.
+func (s *Settings) Set(key string, value interface{}) error {
+	defer platform.Recover()
+	if err := config.Set(key, value); err != nil {
+		return err
+	}
+	s.log.Info("setting changed: ", key)
+	return config.Save()
+}
@@ -0,0 +1,217 @@
+package features
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"{{.ProjectName}}/platform"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+const (
+	// maxLogBody is the maximum size of a request to the /log route.
+	maxLogBody = 64 << 10
+	// maxLogMessage is the maximum length of a message; the longer ones
+	// are truncated.
+	maxLogMessage = 8 << 10
+	// maxLogValue is the maximum length of a string field, e.g. a stack
+	// trace.
+	maxLogValue = 16 << 10
+	// maxLogFields is the maximum number of fields of an entry.
+	maxLogFields = 32
+
+	// logRate and logBurst limit the entries per second of a session.
+	logRate  = 20
+	logBurst = 100
+	// maxLogSessions bounds the rate limiters kept at once.
+	maxLogSessions = 64
+)
+
+// WebLogEntry is an entry sent by the web side.
+type WebLogEntry struct {
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields"`
+}
+
+// Feature: Frontend Log
+//
+// WebLog writes the errors and the console output of the web side to the
+// log, with a source=frontend field, through the bound Log method or the
+// /log route. A "session" field identifies a page load, each limited to
+// logRate entries per second.
+type WebLog struct {
+	log *zlog.Component
+
+	mu       sync.Mutex
+	sessions map[string]*logLimiter
+}
+
+func NewWebLog() Feature {
+	return &WebLog{sessions: make(map[string]*logLimiter)}
+}
+
+func (w *WebLog) OnStartup(ctx context.Context) {
+	w.log = zlog.Named("frontend")
+}
+
+func (w *WebLog) OnShutdown(ctx context.Context) {
+	//
+}
+
+func (w *WebLog) Routes(ctx context.Context, e *gin.Engine) {
+	e.POST("/log", func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxLogBody)
+		var entries []WebLogEntry
+		if err := c.ShouldBindJSON(&entries); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, e := range entries {
+			w.write(e)
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// Log writes an entry of the web side to the log. level is one of trace,
+// debug, info, warn and error; console methods such as "log" are mapped
+// to them.
+func (w *WebLog) Log(level, msg string, fields map[string]any) {
+	defer platform.Recover()
+	w.write(WebLogEntry{Level: level, Message: msg, Fields: fields})
+}
+
+func (w *WebLog) write(e WebLogEntry) {
+	session, _ := e.Fields["session"].(string)
+	allowed, dropped := w.limiter(session).allow(time.Now())
+	if dropped > 0 {
+		logger := w.log.With().Str("source", "frontend").Str("session", session).Int("dropped", dropped).Logger()
+		logger.Warn().Msg("entries dropped by the rate limit")
+	}
+	if !allowed {
+		return
+	}
+
+	l := w.log.With().Str("source", "frontend")
+	n := 0
+	for k, v := range e.Fields {
+		if n == maxLogFields {
+			l = l.Bool("truncated", true)
+			break
+		}
+		if k == "source" {
+			continue
+		}
+		if reservedLogField(k) {
+			// a duplicate key would be ambiguous for the log readers
+			k = "field_" + k
+		}
+		if s, ok := v.(string); ok {
+			v = truncate(s, maxLogValue)
+		}
+		l = l.Interface(k, v)
+		n++
+	}
+	logger := l.Logger()
+	logger.WithLevel(webLogLevel(e.Level)).Msg(truncate(e.Message, maxLogMessage))
+}
+
+// reservedLogField reports whether k is a field which zerolog or zlog
+// writes itself.
+func reservedLogField(k string) bool {
+	switch k {
+	case zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName,
+		zerolog.ErrorFieldName, zerolog.ErrorStackFieldName, zerolog.CallerFieldName, "component":
+		return true
+	}
+	return false
+}
+
+// limiter returns the rate limiter of session, forgetting the least
+// recently used session when there are too many.
+func (w *WebLog) limiter(session string) *logLimiter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if l, ok := w.sessions[session]; ok {
+		l.seen = now
+		return l
+	}
+	if len(w.sessions) >= maxLogSessions {
+		var oldest string
+		for k, l := range w.sessions {
+			if oldest == "" || l.seen.Before(w.sessions[oldest].seen) {
+				oldest = k
+			}
+		}
+		delete(w.sessions, oldest)
+	}
+	l := &logLimiter{tokens: logBurst, last: now, seen: now}
+	w.sessions[session] = l
+	return l
+}
+
+// webLogLevel maps the level names of the web side to the log levels.
+// Fatal and panic are not allowed from there.
+func webLogLevel(level string) zerolog.Level {
+	switch strings.ToLower(level) {
+	case "trace":
+		return zerolog.TraceLevel
+	case "debug":
+		return zerolog.DebugLevel
+	case "warn", "warning":
+		return zerolog.WarnLevel
+	case "error", "fatal", "panic":
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.InfoLevel
+	}
+}
+
+// truncate cuts s to at most n bytes, on a rune boundary.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "… (" + strconv.Itoa(len(s)-n) + " bytes truncated)"
+}
+
+// logLimiter is a token bucket of logRate tokens per second, up to
+// logBurst.
+type logLimiter struct {
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	dropped int
+
+	seen time.Time // guarded by WebLog.mu
+}
+
+// allow takes a token. It returns the number of entries dropped before
+// when it succeeds again, so it can be reported once.
+func (l *logLimiter) allow(now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(logBurst, l.tokens+now.Sub(l.last).Seconds()*logRate)
+	l.last = now
+	if l.tokens < 1 {
+		l.dropped++
+		return false, 0
+	}
+	l.tokens--
+	dropped := l.dropped
+	l.dropped = 0
+	return true, dropped
+}
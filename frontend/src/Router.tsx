@@ -3,6 +3,7 @@ import { Layout } from "./layout"
 import { ComponentRoutes } from "./pages/Component"
 import { FormRoutes } from "./pages/Form"
 import { Home } from "./pages/Home"
+import { LogsRoutes } from "./pages/Logs"
 import { NotFound } from "./pages/NotFound"
 import { TableRoutes } from "./pages/Table"
 
@@ -16,6 +17,7 @@ export const router = createBrowserRouter(
 			{ComponentRoutes}
 			{FormRoutes}
 			{TableRoutes}
+			{LogsRoutes}
 		</Route>,
 	),
 )
@@ -53,6 +53,21 @@ export const toggleMaximizeWindow = createAction("toggleMaximizeWindow")
 export const isMaximizedWindow = createAction<boolean>("isMaximizedWindow")
 export const images = createAction<string | string[]>("images")
 
+export interface CatalogEntry {
+	id: string
+	name: string
+	dir: string
+	source: "args" | "drop"
+}
+
+export const catalog = createAction<CatalogEntry[]>("catalog")
+
+/** The "crashReport" event: canShow tells whether ShowCrashReport works. */
+export interface CrashReportNotice {
+	path: string
+	canShow: boolean
+}
+
 export const userDataPath = createAction<string>("userDataPath")
 
 export const data = createAction<string>("data")
@@ -1,7 +1,9 @@
-import { emitWails, wailsEvents } from "@context/events"
+import { emitWails, wailsEvents, windowEvents } from "@context/events"
 import * as base from "@wails/features/Base"
 import * as app from "@wails/main/App"
 import * as runtime from "@wails/runtime"
+import { createElement } from "react"
+import { FormattedMessage } from "react-intl"
 import { eventChannel } from "redux-saga"
 import { call, fork, put, takeEvery } from "redux-saga/effects"
 import * as ac from "./action"
@@ -34,6 +36,27 @@ export function* appWindow() {
 	yield takeEvery(wailsEvents<string | string[]>("images"), function* (data) {
 		yield put(ac.images(data))
 	})
+	yield takeEvery(wailsEvents<ac.CatalogEntry[]>("catalog"), function* (data) {
+		yield put(ac.catalog(data))
+	})
+	yield takeEvery(wailsEvents<ac.CrashReportNotice>("crashReport"), function* ({ path, canShow }) {
+		yield put(
+			ac.toast({
+				variant: "destructive",
+				delay: 0,
+				title: createElement(FormattedMessage, { id: "crash_report" }),
+				description: path,
+				// without a file explorer, the path is all there is to show
+				action: canShow
+					? createElement(
+							"button",
+							{ onClick: () => app.ShowCrashReport(path) },
+							createElement(FormattedMessage, { id: "show" }),
+						)
+					: undefined,
+			}),
+		)
+	})
 	yield fork(function* () {
 		let timer: number
 		const ch = eventChannel<boolean>(emit => {
@@ -53,5 +76,8 @@ export function* appWindow() {
 			yield put(ac.isMaximizedWindow(data))
 		})
 	})
+	yield takeEvery(windowEvents("beforeunload"), function* () {
+		yield call(emitWails, "webUnload")
+	})
 	yield fork(emitWails, "webReady")
 }
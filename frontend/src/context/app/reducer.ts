@@ -6,12 +6,14 @@ export interface AppStore {
 	userDataPath: string
 	isMaximized: boolean
 	images?: string | string[]
+	catalog: ac.CatalogEntry[]
 }
 
 const init: AppStore = {
 	toasts: {},
 	userDataPath: "",
 	isMaximized: false,
+	catalog: [],
 }
 
 export const app = createReducer(init, builder =>
@@ -22,6 +24,9 @@ export const app = createReducer(init, builder =>
 		.addCase(ac.images, (state, { payload }) => {
 			state.images = payload
 		})
+		.addCase(ac.catalog, (state, { payload }) => {
+			state.catalog = payload
+		})
 		.addCase(ac.userDataPath, (state, { payload }) => {
 			state.userDataPath = payload
 		})
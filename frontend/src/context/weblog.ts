@@ -0,0 +1,87 @@
+// Sends the errors and the console output of the web side to the log of
+// the app, by batches to the /log route.
+
+type Level = "trace" | "debug" | "info" | "warn" | "error"
+
+interface Entry {
+	level: Level
+	message: string
+	fields: Record<string, unknown>
+}
+
+const session = Math.random().toString(36).slice(2)
+const maxQueue = 200
+let queue: Entry[] = []
+let dropped = 0
+let timer: number | undefined
+
+function format(v: unknown): string {
+	if (v instanceof Error) {
+		return v.message
+	}
+	if (typeof v === "string") {
+		return v
+	}
+	try {
+		return JSON.stringify(v)
+	} catch {
+		return String(v)
+	}
+}
+
+function send(level: Level, message: string, fields: Record<string, unknown> = {}) {
+	if (queue.length >= maxQueue) {
+		dropped++
+		return
+	}
+	queue.push({ level, message, fields: { ...fields, session } })
+	if (timer == undefined) {
+		timer = window.setTimeout(flush, 1000)
+	}
+}
+
+function flush() {
+	window.clearTimeout(timer)
+	timer = undefined
+	if (dropped > 0) {
+		queue.push({ level: "warn", message: "web log queue full", fields: { session, dropped } })
+		dropped = 0
+	}
+	if (queue.length === 0) {
+		return
+	}
+	const body = JSON.stringify(queue)
+	queue = []
+	fetch("/log", { method: "POST", headers: { "Content-Type": "application/json" }, body, keepalive: true }).catch(
+		() => void 0,
+	)
+}
+
+export function installWebLog() {
+	window.addEventListener("error", e => {
+		send("error", e.message, {
+			file: e.filename,
+			line: e.lineno,
+			column: e.colno,
+			stack: e.error instanceof Error ? e.error.stack : undefined,
+		})
+	})
+	window.addEventListener("unhandledrejection", e => {
+		const reason: unknown = e.reason
+		send("error", "unhandled rejection: " + format(reason), {
+			stack: reason instanceof Error ? reason.stack : undefined,
+		})
+	})
+	window.addEventListener("beforeunload", flush)
+
+	const methods: Record<string, Level> = { debug: "debug", log: "info", info: "info", warn: "warn", error: "error" }
+	for (const [method, level] of Object.entries(methods)) {
+		const key = method as "debug" | "log" | "info" | "warn" | "error"
+		const original = console[key]
+		console[key] = (...args: unknown[]) => {
+			original.apply(console, args)
+			const err = args.find(a => a instanceof Error) as Error | undefined
+			send(level, args.map(format).join(" "), { console: method, stack: err?.stack })
+		}
+	}
+}
@@ -1,5 +1,9 @@
 import { createRoot } from "react-dom/client"
+import { installWebLog } from "@context/weblog"
 import { App } from "./App"
+
+installWebLog()
+
 const rootEl = document.getElementById("root")
 if (rootEl) {
 	const root = createRoot(rootEl)
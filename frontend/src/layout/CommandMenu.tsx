@@ -58,6 +58,14 @@ export function CommandMenu() {
 					>
 						Table
 					</CommandItem>
+					<CommandItem
+						onSelect={() => {
+							setVisible(false)
+							navigate("/logs")
+						}}
+					>
+						Logs
+					</CommandItem>
 				</CommandGroup>
 			</CommandList>
 		</CommandDialog>
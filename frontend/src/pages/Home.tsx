@@ -78,6 +78,7 @@ export function Home() {
 				<div tw="pt-3 mx-auto max-w-[var(--dialog-width)]">
 					<MD5Form />
 					<Demo />
+					<Catalog />
 				</div>
 			</div>
 		</article>
@@ -127,6 +128,25 @@ function Buttons() {
 	)
 }
 
+function Catalog() {
+	const catalog = useSelect(state => state.app.catalog)
+	if (catalog.length === 0) {
+		return null
+	}
+	return (
+		<div tw="pt-3 pb-1 grid grid-cols-3 gap-3">
+			{catalog.map(e => (
+				<figure key={e.id}>
+					<img src={"/catalog/" + e.id} />
+					<figcaption tw="text-sm truncate" title={e.dir}>
+						{e.name} ({e.source})
+					</figcaption>
+				</figure>
+			))}
+		</div>
+	)
+}
+
 function Demo() {
 	const images = useSelect(state => state.app.images)
 	return (
@@ -0,0 +1,223 @@
+import { Badge, type BadgeProps } from "@components/badage"
+import { Button } from "@components/button"
+import { Checkbox } from "@components/checkbox"
+import { SearchInput } from "@components/input"
+import { Label } from "@components/label"
+import { Switch } from "@components/switch"
+import * as logs from "@wails/features/Logs"
+import { features, zlog } from "@wails/models"
+import * as runtime from "@wails/runtime"
+import { useCallback, useEffect, useId, useState } from "react"
+import { FormattedDate, FormattedMessage, useIntl } from "react-intl"
+import { Header } from "~/pages/common"
+
+const levels = ["trace", "debug", "info", "warn", "error", "fatal", "panic"]
+
+// the details hidden by default
+const defaultLevels = ["info", "warn", "error", "fatal", "panic"]
+
+const pageSize = 100
+
+// the entries kept while following the log
+const maxFollowed = 2000
+
+export function Component() {
+	const intl = useIntl()
+	const [files, setFiles] = useState<features.LogFile[]>([])
+	const [file, setFile] = useState("")
+	const [selected, setSelected] = useState(defaultLevels)
+	const [text, setText] = useState("")
+	const [search, setSearch] = useState("")
+	const [entries, setEntries] = useState<zlog.Entry[]>([])
+	const [total, setTotal] = useState(0)
+	const [dropped, setDropped] = useState(0)
+	const [error, setError] = useState("")
+	const [follow, setFollow] = useState(false)
+
+	useEffect(() => {
+		logs.Files().then(setFiles, e => setError(String(e)))
+	}, [])
+
+	// search once the typing pauses
+	useEffect(() => {
+		const timer = window.setTimeout(() => setSearch(text), 300)
+		return () => window.clearTimeout(timer)
+	}, [text])
+
+	const load = useCallback(
+		(offset: number) => {
+			const q = features.LogQuery.createFrom({ file, levels: selected, text: search, offset, limit: pageSize })
+			logs.Query(q).then(
+				page => {
+					setEntries(prev => (offset > 0 ? [...prev, ...page.entries] : page.entries))
+					setTotal(page.total)
+					setError("")
+				},
+				e => setError(String(e)),
+			)
+		},
+		[file, selected, search],
+	)
+
+	useEffect(() => {
+		load(0)
+		setDropped(0)
+	}, [load])
+
+	useEffect(() => {
+		if (!follow || file !== "") {
+			return
+		}
+		const off = runtime.EventsOn("logTail", (batch: features.LogBatch) => {
+			const added = [...batch.entries].reverse()
+			setEntries(prev => [...added, ...prev].slice(0, maxFollowed))
+			setTotal(total => total + added.length)
+			setDropped(dropped => dropped + batch.dropped)
+			// the next batch is sent once this one is shown
+			window.requestAnimationFrame(() => logs.TailAck())
+		})
+		const q = features.LogQuery.createFrom({ levels: selected, text: search })
+		logs.Subscribe(q).catch(e => setError(String(e)))
+		return () => {
+			off()
+			logs.Unsubscribe()
+		}
+	}, [follow, file, selected, search])
+
+	const fileId = useId()
+	const followId = useId()
+	const levelsId = useId()
+	return (
+		<article tw="px-5 max-w-5xl mx-auto pb-20">
+			<Header>
+				<FormattedMessage id="logs" />
+			</Header>
+			<div tw="flex flex-wrap items-center gap-4 mb-3">
+				<div tw="flex items-center gap-2">
+					<Label htmlFor={fileId}>
+						<FormattedMessage id="logs_file" />
+					</Label>
+					<select
+						id={fileId}
+						tw="h-[34px] rounded-md border border-input bg-background px-2 text-sm"
+						value={file}
+						onChange={e => setFile(e.target.value)}
+					>
+						{files.map((f, i) => (
+							<option key={f.name} value={i === 0 ? "" : f.name}>
+								{i === 0 ? intl.formatMessage({ id: "logs_current" }) : f.name}
+							</option>
+						))}
+					</select>
+				</div>
+				<div tw="flex items-center gap-2">
+					<Switch
+						id={followId}
+						checked={follow && file === ""}
+						disabled={file !== ""}
+						onChange={e => setFollow(e.target.checked)}
+					/>
+					<Label htmlFor={followId}>
+						<FormattedMessage id="logs_follow" />
+					</Label>
+				</div>
+				<SearchInput
+					tw="max-w-xs"
+					placeholder={intl.formatMessage({ id: "logs_search" })}
+					value={text}
+					onChange={e => setText(e.target.value)}
+				/>
+			</div>
+			<div tw="flex flex-wrap gap-4 mb-4">
+				{levels.map(level => (
+					<div key={level} tw="flex items-center gap-2">
+						<Checkbox
+							id={levelsId + level}
+							checked={selected.includes(level)}
+							onChange={e =>
+								setSelected(prev => (e.target.checked ? [...prev, level] : prev.filter(l => l !== level)))
+							}
+						/>
+						<Label htmlFor={levelsId + level}>
+							<FormattedMessage id={"log_level_" + level} />
+						</Label>
+					</div>
+				))}
+			</div>
+			{error && <p tw="text-destructive mb-3">{error}</p>}
+			{dropped > 0 && (
+				<p tw="text-muted-foreground text-sm mb-3">
+					<FormattedMessage id="logs_dropped" values={{ count: dropped }} />
+				</p>
+			)}
+			{entries.length === 0 ? (
+				<p tw="text-muted-foreground">
+					<FormattedMessage id="logs_empty" />
+				</p>
+			) : (
+				<ul tw="grid gap-1 font-mono text-sm">
+					{entries.map((e, i) => (
+						<LogRow key={i} entry={e} />
+					))}
+				</ul>
+			)}
+			{entries.length < total && (
+				<div tw="flex justify-center mt-4">
+					<Button variant="outline" size="sm" onClick={() => load(entries.length)}>
+						<FormattedMessage id="logs_more" />
+					</Button>
+				</div>
+			)}
+		</article>
+	)
+}
+
+function LogRow({ entry }: { entry: zlog.Entry }) {
+	const time = entry.time ? new Date(entry.time) : undefined
+	const fields = Object.entries(entry.fields ?? {})
+	return (
+		<li tw="flex gap-3 items-baseline border-b py-1">
+			<span tw="text-muted-foreground whitespace-nowrap">
+				{time && !isNaN(time.getTime()) ? (
+					<FormattedDate
+						value={time}
+						year="numeric"
+						month="2-digit"
+						day="2-digit"
+						hour="2-digit"
+						minute="2-digit"
+						second="2-digit"
+					/>
+				) : (
+					entry.time
+				)}
+			</span>
+			{entry.level && (
+				<Badge variant={levelVariant(entry.level)}>
+					<FormattedMessage id={"log_level_" + entry.level} defaultMessage={entry.level} />
+				</Badge>
+			)}
+			<span tw="break-all">
+				{entry.message}
+				{fields.map(([k, v]) => (
+					<span key={k} tw="ml-2 text-muted-foreground">
+						{k}={typeof v === "string" ? v : JSON.stringify(v)}
+					</span>
+				))}
+			</span>
+		</li>
+	)
+}
+
+function levelVariant(level: string): BadgeProps["variant"] {
+	switch (level) {
+		case "error":
+		case "fatal":
+		case "panic":
+			return "destructive"
+		case "warn":
+			return "default"
+		default:
+			return "secondary"
+	}
+}
@@ -0,0 +1,3 @@
+import { Route } from "react-router-dom"
+
+export const LogsRoutes = <Route path="logs" lazy={() => import("./LogsPage")} />
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"time"
 
 	"{{.ProjectName}}/config"
 	"{{.ProjectName}}/platform"
@@ -22,17 +25,166 @@ func appContext() (context.Context, context.CancelFunc) {
 	return ctx, cancel
 }
 
-func main() {
-	if err := config.Parse(); err != nil {
-		panic(err)
+// logFile is written by the app and read by "logs tail".
+var logFile = filepath.Join(platform.UserDataPath(), "log")
+
+// absArgs makes the arguments which are existing paths absolute, so they
+// can be forwarded to the running instance.
+func absArgs(args []string) []string {
+	abs := make([]string, len(args))
+	for i, arg := range args {
+		abs[i] = arg
+		if _, err := os.Stat(arg); err == nil {
+			if p, err := filepath.Abs(arg); err == nil {
+				abs[i] = p
+			}
+		}
+	}
+	return abs
+}
+
+// lockInstance takes the single instance lock, except when wails runs the
+// app to generate the bindings or builds it for "wails dev": the app may
+// be running meanwhile, and the run must reach wails.Run.
+func lockInstance() (*platform.Instance, error) {
+	if platform.IsBindings() || platform.IsDev() {
+		return nil, nil
 	}
+	return platform.LockInstance(platform.UserDataPath())
+}
+
+// interactiveLaunch reports whether the output of the process isn't
+// captured by another program, such as a script or a build tool.
+func interactiveLaunch() bool {
+	info, err := os.Stdout.Stat()
+	return err != nil || info.Mode()&os.ModeNamedPipe == 0
+}
+
+// setRedaction hides the secrets of the config and the values it
+// configures from the log.
+func setRedaction(c config.Configuration) {
+	zlog.SetRedactValues(config.SecretValues()...)
+	zlog.RedactHome(c.LogRedact.Home)
+	if err := zlog.SetRedactPatterns(c.LogRedact.Patterns); err != nil {
+		zlog.Warn("log redaction: ", err)
+	}
+}
+
+func setSampling(c config.LogSampling) {
+	if err := zlog.SetSampling(zlog.SampleOptions{Window: c.Window, Rates: c.Rates}); err != nil {
+		zlog.Warn(err)
+	}
+}
+
+// stderrSink mirrors the log to stderr in debug builds, unless the config
+// says otherwise.
+func stderrSink(c config.LogStderr) *zlog.SinkOptions {
+	if c.Enabled == "off" || c.Enabled == "auto" && !platform.IsDebug() {
+		return nil
+	}
+	return &zlog.SinkOptions{Level: c.Level, Format: c.Format, Color: c.Color}
+}
+
+func journalSink(c config.LogJournald) *zlog.SinkOptions {
+	if !c.Enabled {
+		return nil
+	}
+	return &zlog.SinkOptions{Level: c.Level}
+}
+
+func rotateOptions(r config.LogRotate) zlog.RotateOptions {
+	return zlog.RotateOptions{
+		MaxSize:    int64(r.MaxSizeMB) << 20,
+		Daily:      r.Daily,
+		MaxBackups: r.MaxBackups,
+		Timestamp:  r.Naming == "timestamp",
+		Compress:   r.Compress,
+		MaxAge:     r.MaxAge,
+		MaxTotal:   int64(r.MaxTotalMB) << 20,
+	}
+}
+
+func main() {
+	platform.HandleCrash(reportCrash)
+	defer platform.Recover()
 
 	ctx, cancel := appContext()
 	defer cancel()
 
-	if err := zlog.SetFileLogger(filepath.Join(platform.UserDataPath(), "log"), config.Config.LogLevel); err != nil {
+	if code, ok := runSubcommand(ctx, os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
+	err := config.Parse(commandLine, os.Args[1:])
+	if code, ok := runCommand(err); ok {
+		os.Exit(code)
+	}
+	if err != nil {
+		// a mistake in the config, not a crash
+		os.Exit(fail(err))
+	}
+
+	// before the file logger, which the running instance owns: this
+	// process only forwards its arguments to it
+	instance, lockErr := lockInstance()
+	switch {
+	case errors.Is(lockErr, platform.ErrInstanceRunning):
+		args := absArgs(commandLine.Args())
+		if len(args) == 0 && !interactiveLaunch() {
+			// nothing to open, and no user waiting for the window
+			return
+		}
+		if err := platform.ForwardArgs(platform.UserDataPath(), args); err != nil {
+			panic(err)
+		}
+		return
+	case lockErr == nil && instance != nil:
+		defer instance.Close()
+	}
+
+	if err := zlog.SetFileLogger(logFile, zlog.Options{
+		Level:  config.Config.LogLevel,
+		Format: config.Config.LogFormat,
+		Rotate: rotateOptions(config.Config.LogRotate),
+		Buffer: config.Config.LogBuffer,
+		Block:  config.Config.LogOverflow == "block",
+
+		FileLevel: config.Config.LogFileLevel,
+		Stderr:    stderrSink(config.Config.LogStderr),
+		Journal:   journalSink(config.Config.LogJournald),
+		Name:      platform.ProjectName,
+	}); err != nil {
 		panic(err)
 	}
+	defer zlog.Close()
+	if err := zlog.SetLevels(config.Config.LogLevels); err != nil {
+		zlog.Warn(err)
+	}
+	setRedaction(config.Config)
+	setSampling(config.Config.LogSampling)
+	zlog.Debug("config: ", config.Config)
+	if lockErr != nil {
+		zlog.Warn("single instance: ", lockErr)
+	}
+
+	config.Subscribe(func(prev, cur config.Configuration) {
+		if prev.LogLevel != cur.LogLevel {
+			zlog.SetLevel(cur.LogLevel)
+		}
+		if !maps.Equal(prev.LogLevels, cur.LogLevels) {
+			if err := zlog.SetLevels(cur.LogLevels); err != nil {
+				zlog.Warn(err)
+			}
+		}
+		if prev.LogRotate != cur.LogRotate {
+			zlog.SetRotate(rotateOptions(cur.LogRotate))
+		}
+		setRedaction(cur)
+		if prev.LogSampling.Window != cur.LogSampling.Window || !maps.Equal(prev.LogSampling.Rates, cur.LogSampling.Rates) {
+			setSampling(cur.LogSampling)
+		}
+	})
+	platform.Go(func() { config.Watch(ctx, 2*time.Second) })
 
-	NewApp().Run(ctx)
+	NewApp(commandLine.Args(), instance).Run(ctx)
 }
@@ -0,0 +1,9 @@
+//go:build !bindings
+
+package platform
+
+// IsBindings reports whether the app is built by wails to generate the
+// bindings of the frontend, rather than to run.
+func IsBindings() bool {
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build dev
+
+package platform
+
+// IsDev reports whether the app is built by "wails dev".
+func IsDev() bool {
+	return true
+}
@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CrashReport is what is known about a crash of the app.
+type CrashReport struct {
+	Time   time.Time
+	Error  string
+	Stack  string
+	Logs   []string // the last lines of the log
+	Config string   // with the secrets redacted
+}
+
+// crashHandler is called with the panics recovered by Recover.
+var crashHandler func(e any)
+
+// HandleCrash sets the function called with the panics recovered by
+// Recover. It is expected to report the crash and exit.
+func HandleCrash(fn func(e any)) {
+	crashHandler = fn
+}
+
+// Recover hands a panic to the crash handler, or panics again if there is
+// none. It must be deferred first by main and by the goroutines of the
+// app, including the bound methods and the event callbacks, which wails
+// calls in goroutines of its own without recovering their panics.
+func Recover() {
+	e := recover()
+	if e == nil {
+		return
+	}
+	if crashHandler == nil {
+		panic(e)
+	}
+	crashHandler(e)
+}
+
+// Go runs fn in a goroutine whose panics are handled by Recover.
+func Go(fn func()) {
+	go func() {
+		defer Recover()
+		fn()
+	}()
+}
+
+// pendingSuffix marks the reports which haven't been offered to the user
+// yet.
+const pendingSuffix = ".pending"
+
+// CrashDir returns the directory of the crash reports.
+func CrashDir() string {
+	return filepath.Join(UserDataPath(), "crashes")
+}
+
+// WriteCrashReport writes r to a zip bundle in CrashDir and returns its
+// path. The bundle is pending until AckCrashReport is called.
+func WriteCrashReport(r CrashReport) (string, error) {
+	dir := CrashDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, "crash-"+r.Time.Format("20060102-150405")+".zip")
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(f)
+	files := []struct{ name, content string }{
+		{"crash.txt", r.Time.Format(time.RFC3339) + "\n" + r.Error + "\n\n" + r.Stack},
+		{"log.txt", strings.Join(r.Logs, "\n") + "\n"},
+		{"config.txt", r.Config + "\n"},
+		{"system.txt", systemInfo()},
+	}
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: r.Time})
+		if err == nil {
+			_, err = w.Write([]byte(file.content))
+		}
+		if err != nil {
+			f.Close()
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return name, os.WriteFile(name+pendingSuffix, nil, 0600)
+}
+
+// systemInfo describes the versions of the app and the system.
+func systemInfo() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "app: %s\n", ProjectName)
+	fmt.Fprintf(sb, "wails: %s\n", WailsVersion)
+	fmt.Fprintf(sb, "go: %s\n", runtime.Version())
+	fmt.Fprintf(sb, "os: %s\n", OSVersion())
+	fmt.Fprintf(sb, "arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(sb, "cpus: %d\n", runtime.NumCPU())
+	fmt.Fprintf(sb, "debug: %t\n", IsDebug())
+	return sb.String()
+}
+
+// PendingCrashReports returns the paths of the crash reports which
+// haven't been acknowledged, the newest first.
+func PendingCrashReports() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(CrashDir(), "*.zip"+pendingSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var reports []string
+	for _, m := range matches {
+		name := strings.TrimSuffix(m, pendingSuffix)
+		if _, err := os.Stat(name); err != nil {
+			// the report has been removed
+			_ = os.Remove(m)
+			continue
+		}
+		reports = append(reports, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(reports)))
+	return reports, nil
+}
+
+// AckCrashReport marks the crash report name as offered to the user.
+func AckCrashReport(name string) error {
+	err := os.Remove(filepath.Join(CrashDir(), filepath.Base(name)) + pendingSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -12,6 +12,8 @@ import (
 	"{{.ProjectName}}/platform/zlog"
 )
 
+var execLog = zlog.Named("exec")
+
 type ExecOptions struct {
 	Ctx          context.Context
 	Env          []string
@@ -60,20 +62,20 @@ func ExecWithOptions(options ExecOptions, cmdstr string, args ...string) (stdout
 			if options.InBackground {
 				switch options.LogLevel {
 				default:
-					zlog.Tracef("EXEC %s %s", cmdstr, strings.Join(args, " "))
+					execLog.Tracef("EXEC %s %s", cmdstr, strings.Join(args, " "))
 				case "info", "INFO":
-					zlog.Infof("EXEC %s %s", cmdstr, strings.Join(args, " "))
+					execLog.Infof("EXEC %s %s", cmdstr, strings.Join(args, " "))
 				case "debug", "DEBUG":
-					zlog.Debugf("EXEC %s %s", cmdstr, strings.Join(args, " "))
+					execLog.Debugf("EXEC %s %s", cmdstr, strings.Join(args, " "))
 				}
 			} else {
 				switch options.LogLevel {
 				default:
-					zlog.Tracef("EXEC %s %s", cmdstr, strings.Join(args, " "))
+					execLog.Tracef("EXEC %s %s", cmdstr, strings.Join(args, " "))
 				case "info", "INFO":
-					zlog.Infof("(%v) EXEC %s %s", time.Since(t), cmdstr, strings.Join(args, " "))
+					execLog.Infof("(%v) EXEC %s %s", time.Since(t), cmdstr, strings.Join(args, " "))
 				case "debug", "DEBUG":
-					zlog.Debugf("(%v) EXEC %s %s", time.Since(t), cmdstr, strings.Join(args, " "))
+					execLog.Debugf("(%v) EXEC %s %s", time.Since(t), cmdstr, strings.Join(args, " "))
 				}
 			}
 		}()
@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// ErrInstanceRunning is returned by LockInstance when another instance of
+// the app holds the lock.
+var ErrInstanceRunning = errors.New("another instance is running")
+
+// Instance is the lock of the single running instance of the app. Later
+// launches forward their arguments to it through a Unix domain socket.
+type Instance struct {
+	lock *os.File
+	ln   net.Listener
+}
+
+type instanceMessage struct {
+	Args []string `json:"args"`
+}
+
+func instancePaths(dir string) (lock, socket string) {
+	return filepath.Join(dir, "instance.lock"), filepath.Join(dir, "instance.sock")
+}
+
+// LockInstance takes the instance lock in dir and listens for the
+// arguments of later launches. It returns ErrInstanceRunning if another
+// instance holds the lock.
+func LockInstance(dir string) (*Instance, error) {
+	lockPath, socketPath := instancePaths(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// the socket of a crashed instance is left behind
+	_ = os.Remove(socketPath)
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	_ = os.Chmod(socketPath, 0600)
+	return &Instance{lock: f, ln: ln}, nil
+}
+
+// Serve calls fn with the arguments forwarded by each later launch,
+// until ctx is done or the instance is closed.
+func (i *Instance) Serve(ctx context.Context, fn func(args []string)) {
+	go func() {
+		<-ctx.Done()
+		i.ln.Close()
+	}()
+	for {
+		conn, err := i.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				zlog.Warn("instance: ", err)
+			}
+			return
+		}
+
+		var msg instanceMessage
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		err = json.NewDecoder(io.LimitReader(conn, 1<<20)).Decode(&msg)
+		conn.Close()
+		if err != nil {
+			zlog.Warn("instance: ", err)
+			continue
+		}
+		fn(msg.Args)
+	}
+}
+
+// Close releases the instance lock.
+func (i *Instance) Close() error {
+	i.ln.Close()
+	return i.lock.Close()
+}
+
+// ForwardArgs sends args to the instance running in dir. Relative paths
+// should be made absolute beforehand, the working directories differ.
+func ForwardArgs(dir string, args []string) error {
+	_, socketPath := instancePaths(dir)
+
+	// the running instance may still be starting
+	var conn net.Conn
+	var err error
+	for try := 0; try < 20; try++ {
+		if conn, err = net.Dial("unix", socketPath); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return json.NewEncoder(conn).Encode(instanceMessage{Args: args})
+}
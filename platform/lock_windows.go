@@ -0,0 +1,35 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32    = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx = modkernel32.NewProc("LockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+// lockFile takes an exclusive lock of f, which is released when f is
+// closed or the process exits.
+func lockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	r1, _, err := syscall.SyscallN(procLockFileEx.Addr(), f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r1 != 0 {
+		return nil
+	}
+	if errors.Is(err, errorLockViolation) {
+		return ErrInstanceRunning
+	}
+	return err
+}
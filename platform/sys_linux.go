@@ -2,8 +2,34 @@
 
 package platform
 
-import "errors"
+import (
+	"errors"
+	"os"
+	"strings"
+)
 
 func OpenFileExplorer(target string) error {
 	return errors.ErrUnsupported
 }
+
+// CanOpenFileExplorer reports whether OpenFileExplorer is supported.
+func CanOpenFileExplorer() bool {
+	return false
+}
+
+// OSVersion describes the distribution and the kernel, e.g. for a crash
+// report.
+func OSVersion() string {
+	name := "Linux"
+	if b, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			if v, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				name = strings.Trim(v, `"`)
+			}
+		}
+	}
+	if b, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		name += " (kernel " + strings.TrimSpace(string(b)) + ")"
+	}
+	return name
+}
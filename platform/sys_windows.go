@@ -3,6 +3,7 @@
 package platform
 
 import (
+	"fmt"
 	"path/filepath"
 	"syscall"
 	"unsafe"
@@ -11,8 +12,26 @@ import (
 var (
 	modshell32        = syscall.NewLazyDLL("shell32.dll")
 	procShellExecuteW = modshell32.NewProc("ShellExecuteW")
+
+	modntdll          = syscall.NewLazyDLL("ntdll.dll")
+	procRtlGetVersion = modntdll.NewProc("RtlGetVersion")
 )
 
+// osVersionInfo is RTL_OSVERSIONINFOW.
+type osVersionInfo struct {
+	size        uint32
+	major       uint32
+	minor       uint32
+	build       uint32
+	platformID  uint32
+	servicePack [128]uint16
+}
+
+// CanOpenFileExplorer reports whether OpenFileExplorer is supported.
+func CanOpenFileExplorer() bool {
+	return true
+}
+
 func OpenFileExplorer(target string) error {
 	target = filepath.Clean(target)
 	p1, err := syscall.UTF16PtrFromString("open")
@@ -29,3 +48,17 @@ func OpenFileExplorer(target string) error {
 	}
 	return nil
 }
+
+// OSVersion describes the version of Windows, e.g. for a crash report.
+func OSVersion() string {
+	info := osVersionInfo{}
+	info.size = uint32(unsafe.Sizeof(info))
+	if r1, _, _ := syscall.SyscallN(procRtlGetVersion.Addr(), uintptr(unsafe.Pointer(&info))); r1 != 0 {
+		return "Windows"
+	}
+	v := fmt.Sprintf("Windows %d.%d.%d", info.major, info.minor, info.build)
+	if sp := syscall.UTF16ToString(info.servicePack[:]); sp != "" {
+		v += " " + sp
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package zlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// asyncItem is an entry to write, or a flush request when flushed is set.
+type asyncItem struct {
+	level   zerolog.Level
+	p       []byte
+	flushed chan struct{}
+}
+
+// asyncWriter hands the entries to a single goroutine writing them to w
+// through a bounded buffer. When the buffer is full, Write waits if block
+// is set, and drops the entry otherwise; the number of dropped entries
+// is logged with the next one written.
+type asyncWriter struct {
+	w     io.Writer
+	block bool
+
+	mu     sync.RWMutex // guards closed against the sends to ch
+	closed bool
+	ch     chan asyncItem
+	done   chan struct{}
+
+	dropped atomic.Int64
+
+	// syncMu serializes the writes once closed
+	syncMu sync.Mutex
+}
+
+func newAsyncWriter(w io.Writer, size int, block bool) *asyncWriter {
+	a := &asyncWriter{
+		w:     w,
+		block: block,
+		ch:    make(chan asyncItem, size),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (a *asyncWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		a.syncMu.Lock()
+		defer a.syncMu.Unlock()
+		return writeLevel(a.w, level, p)
+	}
+	defer a.mu.RUnlock()
+
+	// zerolog reuses p once Write returns
+	item := asyncItem{level: level, p: bytes.Clone(p)}
+	if a.block {
+		a.ch <- item
+		return len(p), nil
+	}
+	select {
+	case a.ch <- item:
+	default:
+		a.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for item := range a.ch {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if n := a.dropped.Swap(0); n > 0 {
+			l := zerolog.New(a.w)
+			l.Warn().Timestamp().Int64("dropped", n).Msg("log buffer full, entries dropped")
+		}
+		if _, err := writeLevel(a.w, item.level, item.p); err != nil {
+			// the logger itself can't be used here
+			fmt.Fprintln(os.Stderr, "zlog:", err)
+		}
+	}
+}
+
+// Flush waits until the entries written before are written to w.
+func (a *asyncWriter) Flush() {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	a.ch <- asyncItem{flushed: flushed}
+	a.mu.RUnlock()
+	<-flushed
+}
+
+// Close writes the queued entries and stops the goroutine. The later
+// entries are written synchronously.
+func (a *asyncWriter) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.ch)
+	a.mu.Unlock()
+	<-a.done
+}
@@ -0,0 +1,223 @@
+package zlog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// unset is the level of a component which follows the default level.
+const unset = math.MinInt32
+
+// Component is a named logger, e.g. Named("exec"), whose level can be set
+// apart from the default level. Its entries have a component field.
+type Component struct {
+	name  string
+	level atomic.Int32
+}
+
+var (
+	defaultLevel atomic.Int32
+
+	componentsMu sync.Mutex
+	components   = map[string]*Component{}
+
+	root = &Component{}
+)
+
+func init() {
+	defaultLevel.Store(int32(zerolog.InfoLevel))
+	root.level.Store(unset)
+}
+
+// Named returns the component logger name.
+func Named(name string) *Component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	c, ok := components[name]
+	if !ok {
+		c = &Component{name: name}
+		c.level.Store(unset)
+		components[name] = c
+	}
+	return c
+}
+
+// Name returns the name of the component.
+func (c *Component) Name() string {
+	return c.name
+}
+
+// Level returns the effective level of the component.
+func (c *Component) Level() zerolog.Level {
+	if l := c.level.Load(); l != unset {
+		return zerolog.Level(l)
+	}
+	return zerolog.Level(defaultLevel.Load())
+}
+
+// Run implements zerolog.Hook, discarding the entries below the level of
+// the component.
+func (c *Component) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < c.Level() {
+		e.Discard()
+	}
+}
+
+// Logger returns the zerolog logger of the component.
+func (c *Component) Logger() zerolog.Logger {
+	if c.name == "" {
+		return Logger
+	}
+	return base.With().Str("component", c.name).Logger().Hook(c).Hook(sampling.hook(c.name))
+}
+
+// With returns a context of the component logger to add fields to.
+func (c *Component) With() zerolog.Context {
+	return c.Logger().With().Timestamp()
+}
+
+func parseLevel(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.InfoLevel, nil
+	}
+	return zerolog.ParseLevel(strings.ToLower(level))
+}
+
+// SetComponentLevel changes the level of the component name. An empty
+// level makes it follow the default level again.
+func SetComponentLevel(name, level string) error {
+	c := Named(name)
+	if level == "" {
+		c.level.Store(unset)
+	} else {
+		l, err := parseLevel(level)
+		if err != nil {
+			return err
+		}
+		c.level.Store(int32(l))
+	}
+	updateGlobalLevel()
+	return nil
+}
+
+// SetLevels sets the levels of the components by name, e.g. from the
+// config. The other components follow the default level.
+func SetLevels(levels map[string]string) error {
+	var errs []string
+	componentsMu.Lock()
+	for name, c := range components {
+		if _, ok := levels[name]; !ok {
+			c.level.Store(unset)
+		}
+	}
+	componentsMu.Unlock()
+	for name, level := range levels {
+		if err := SetComponentLevel(name, level); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	updateGlobalLevel()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("log levels: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Levels returns the effective level of each known component.
+func Levels() map[string]string {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	levels := make(map[string]string, len(components))
+	for name, c := range components {
+		levels[name] = c.Level().String()
+	}
+	return levels
+}
+
+// updateGlobalLevel lowers the global level of zerolog to the lowest
+// level in use, so the entries reach the hooks which filter them.
+func updateGlobalLevel() {
+	min := zerolog.Level(defaultLevel.Load())
+	componentsMu.Lock()
+	for _, c := range components {
+		if l := c.level.Load(); l != unset && zerolog.Level(l) < min {
+			min = zerolog.Level(l)
+		}
+	}
+	componentsMu.Unlock()
+	zerolog.SetGlobalLevel(min)
+}
+
+type componentKey struct{}
+
+// WithComponent returns a copy of ctx carrying c.
+func WithComponent(ctx context.Context, c *Component) context.Context {
+	return context.WithValue(ctx, componentKey{}, c)
+}
+
+// FromContext returns the component stored in ctx by WithComponent, or
+// the default logger.
+func FromContext(ctx context.Context) *Component {
+	if c, ok := ctx.Value(componentKey{}).(*Component); ok {
+		return c
+	}
+	return root
+}
+
+func (c *Component) Trace(args ...any) {
+	l := c.Logger()
+	withError(l.Trace(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func (c *Component) Tracef(format string, args ...any) {
+	l := c.Logger()
+	withError(l.Trace(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func (c *Component) Debug(args ...any) {
+	l := c.Logger()
+	withError(l.Debug(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func (c *Component) Debugf(format string, args ...any) {
+	l := c.Logger()
+	withError(l.Debug(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func (c *Component) Info(args ...any) {
+	l := c.Logger()
+	withError(l.Info(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func (c *Component) Infof(format string, args ...any) {
+	l := c.Logger()
+	withError(l.Info(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func (c *Component) Warn(args ...any) {
+	l := c.Logger()
+	withError(l.Warn(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func (c *Component) Warnf(format string, args ...any) {
+	l := c.Logger()
+	withError(l.Warn(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func (c *Component) Error(args ...any) {
+	l := c.Logger()
+	withError(l.Error(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func (c *Component) Errorf(format string, args ...any) {
+	l := c.Logger()
+	withError(l.Error(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,123 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// formatWriter returns a writer converting the JSON lines of zerolog into
+// format: console, json or logfmt. color only applies to console.
+func formatWriter(w io.Writer, format string, color bool) io.Writer {
+	switch format {
+	case "json":
+		return w
+	case "logfmt":
+		return logfmtWriter{w}
+	}
+	return zerolog.ConsoleWriter{Out: w, NoColor: !color, TimeFormat: time.RFC3339}
+}
+
+// logfmtWriter writes each JSON line as key=value pairs, starting with
+// the time, the level and the message.
+type logfmtWriter struct {
+	w io.Writer
+}
+
+func (l logfmtWriter) Write(p []byte) (int, error) {
+	fields, err := decodeFields(p)
+	if err != nil {
+		return 0, err
+	}
+
+	first := []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName}
+	buf := &bytes.Buffer{}
+	write := func(k string, v any) {
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(k)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(v))
+	}
+	for _, k := range first {
+		for _, f := range fields {
+			if f.key == k {
+				write(f.key, f.value)
+			}
+		}
+	}
+	for _, f := range fields {
+		if !slices.Contains(first, f.key) {
+			write(f.key, f.value)
+		}
+	}
+	buf.WriteByte('\n')
+
+	if _, err := l.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type jsonField struct {
+	key   string
+	value any
+}
+
+// decodeFields decodes a JSON object, keeping the order of its keys.
+func decodeFields(p []byte) ([]jsonField, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var fields []jsonField
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		k, _ := t.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		fields = append(fields, jsonField{k, v})
+	}
+	return fields, nil
+}
+
+func logfmtValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	s := logfmtText(v)
+	if s == "" || strings.ContainsAny(s, " =\"\\\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// logfmtText returns a decoded JSON value as text, the strings as they
+// are and the objects and arrays in JSON.
+func logfmtText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
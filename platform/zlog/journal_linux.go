@@ -0,0 +1,119 @@
+//go:build linux
+
+package zlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// journalSocket is the native socket of systemd-journald.
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalWriter sends each JSON line of zerolog to journald as a
+// datagram of fields: MESSAGE, PRIORITY, SYSLOG_IDENTIFIER and the other
+// fields of the entry with their keys in upper case.
+type journalWriter struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+func newJournalWriter(identifier string) (io.Writer, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journalWriter{conn: conn, identifier: identifier}, nil
+}
+
+func (j *journalWriter) Write(p []byte) (int, error) {
+	return j.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (j *journalWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	buf := &bytes.Buffer{}
+	writeJournalField(buf, "PRIORITY", journalPriority(level))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", j.identifier)
+
+	fields, err := decodeFields(p)
+	if err != nil {
+		writeJournalField(buf, "MESSAGE", strings.TrimSpace(string(p)))
+	}
+	for _, f := range fields {
+		switch f.key {
+		case zerolog.MessageFieldName:
+			writeJournalField(buf, "MESSAGE", logfmtText(f.value))
+		case zerolog.LevelFieldName, zerolog.TimestampFieldName:
+			// journald has its own
+		default:
+			if k := journalKey(f.key); k != "" {
+				writeJournalField(buf, k, logfmtText(f.value))
+			}
+		}
+	}
+
+	if _, err := j.conn.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeJournalField writes KEY=value, or the binary form for values with
+// a newline.
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalKey makes a valid journald field name of a key: upper case
+// letters, digits and underscores, not starting with an underscore or a
+// digit.
+func journalKey(key string) string {
+	k := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	k = strings.TrimLeft(k, "_")
+	if k != "" && k[0] >= '0' && k[0] <= '9' {
+		k = "F_" + k
+	}
+	return k
+}
+
+// journalPriority maps a level to a syslog priority.
+func journalPriority(level zerolog.Level) string {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "7"
+	case zerolog.InfoLevel, zerolog.NoLevel:
+		return "6"
+	case zerolog.WarnLevel:
+		return "4"
+	case zerolog.ErrorLevel:
+		return "3"
+	case zerolog.FatalLevel:
+		return "2"
+	case zerolog.PanicLevel:
+		return "0"
+	}
+	return "6"
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package zlog
+
+import (
+	"errors"
+	"io"
+)
+
+func newJournalWriter(identifier string) (io.Writer, error) {
+	return nil, errors.New("journald is only available on Linux")
+}
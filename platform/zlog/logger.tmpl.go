@@ -1,7 +1,8 @@
 package zlog
 
 import (
-	"time"
+	"io"
+	"os"
 
 	"github.com/rs/zerolog"
 
@@ -22,9 +23,32 @@ const (
 )
 
 var (
+	// Logger is the default logger, filtered by the default level.
 	Logger zerolog.Logger
+
+	// base is the unfiltered logger the components derive from.
+	base zerolog.Logger
+
+	file *rotateFile
+
+	// async writes the entries in the background, if enabled
+	async *asyncWriter
 )
 
+// Options configures the file logger.
+type Options struct {
+	Level  string
+	Format string // console (default), json or logfmt
+	Rotate RotateOptions
+	Buffer int  // entries queued for a background writer, 0 to write synchronously
+	Block  bool // wait when the buffer is full instead of dropping entries
+
+	FileLevel string       // minimum level written to the file, on top of the levels of the components
+	Stderr    *SinkOptions // mirror the log to stderr if set
+	Journal   *SinkOptions // send the log to journald if set (Linux)
+	Name      string       // identifier of the app in journald
+}
+
 func marshalStack(err error) interface{} {
 	var sterr errors.StackTraceDescriptor
 	var ok bool
@@ -49,52 +73,109 @@ func marshalStack(err error) interface{} {
 	return sterr.Stack()
 }
 
-func SetFileLogger(filename string, level string) error {
-	switch level {
-	default:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case "trace", "TRACE":
-		zerolog.SetGlobalLevel(zerolog.TraceLevel)
-	case "debug", "DEBUG":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "warn", "WARN":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
-	case "error", "ERROR":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	case "panic", "PANIC":
-		zerolog.SetGlobalLevel(zerolog.PanicLevel)
-	case "fatal", "FATAL":
-		zerolog.SetGlobalLevel(zerolog.FatalLevel)
-	case "disabled":
-		zerolog.SetGlobalLevel(zerolog.Disabled)
+// SetLevel changes the default log level, e.g. after the config is
+// reloaded. Components with a level of their own keep it.
+func SetLevel(level string) {
+	l, err := parseLevel(level)
+	if err != nil {
+		l = zerolog.InfoLevel
 	}
+	defaultLevel.Store(int32(l))
+	updateGlobalLevel()
+}
 
-	file := &rotateFile{
-		filename: filename,
-		maxsize:  10 << 20, // 10 MB
-	}
+func SetFileLogger(filename string, opts Options) error {
+	SetLevel(opts.Level)
 
+	file = newRotateFile(filename, opts.Rotate)
 	if err := file.open(); err != nil {
 		return err
 	}
 
 	// zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	zerolog.ErrorStackMarshaler = marshalStack
-	writer := zerolog.ConsoleWriter{Out: file, NoColor: true, TimeFormat: time.RFC3339}
-
-	Logger = zerolog.New(writer)
+	sinks, journalErr, err := newSinks(opts)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = redactWriter{sinks}
+	if opts.Buffer > 0 {
+		async = newAsyncWriter(w, opts.Buffer, opts.Block)
+		w = async
+	}
+	base = zerolog.New(w)
+	Logger = base.Hook(root).Hook(sampling.hook(""))
 
+	if journalErr != nil {
+		// the app runs without journald, e.g. outside of systemd
+		Warn("journald: ", journalErr)
+	}
 	return nil
 }
 
+// newSinks returns the outputs of the logger: the file, and stderr and
+// journald if enabled. journald is left out if it can't be reached.
+func newSinks(opts Options) (sinks multiSink, journalErr, err error) {
+	fs, err := newSink(formatWriter(io.MultiWriter(file, recent), opts.Format, false), opts.FileLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	sinks = multiSink{fs}
+	if o := opts.Stderr; o != nil {
+		s, err := newSink(formatWriter(os.Stderr, o.Format, o.Color), o.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if o := opts.Journal; o != nil {
+		jw, err := newJournalWriter(opts.Name)
+		if err != nil {
+			return sinks, err, nil
+		}
+		s, err := newSink(jw, o.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil, nil
+}
+
+// Flush waits until the entries logged before are written to the file.
+func Flush() {
+	if async != nil {
+		async.Flush()
+	}
+}
+
+// Close writes the repeated counts and the entries still queued, e.g.
+// on shutdown. The entries logged after are written synchronously.
+func Close() {
+	sampling.flush(true)
+	if async != nil {
+		async.Close()
+	}
+}
+
+// SetRotate changes the rotation of the file logger, e.g. after the
+// config is reloaded.
+func SetRotate(opts RotateOptions) {
+	if file != nil {
+		file.setOptions(opts)
+	}
+}
+
 type wailsLogger struct{}
 
 var WailsLogger = &wailsLogger{}
 
-func (w *wailsLogger) Print(message string)   { Debug(message) }
-func (w *wailsLogger) Trace(message string)   { Trace(message) }
-func (w *wailsLogger) Debug(message string)   { Debug(message) }
-func (w *wailsLogger) Info(message string)    { Info(message) }
-func (w *wailsLogger) Warning(message string) { Warn(message) }
-func (w *wailsLogger) Error(message string)   { Error(message) }
+var wailsLog = Named("wails")
+
+func (w *wailsLogger) Print(message string)   { wailsLog.Debug(message) }
+func (w *wailsLogger) Trace(message string)   { wailsLog.Trace(message) }
+func (w *wailsLogger) Debug(message string)   { wailsLog.Debug(message) }
+func (w *wailsLogger) Info(message string)    { wailsLog.Info(message) }
+func (w *wailsLogger) Warning(message string) { wailsLog.Warn(message) }
+func (w *wailsLogger) Error(message string)   { wailsLog.Error(message) }
 func (w *wailsLogger) Fatal(message string)   { Fatal(message) }
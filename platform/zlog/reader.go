@@ -0,0 +1,263 @@
+package zlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Entry is a line of the log file.
+type Entry struct {
+	Time    string         `json:"time,omitempty"`
+	Level   string         `json:"level,omitempty"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+var levelNames = map[string]string{
+	"TRC": "trace",
+	"DBG": "debug",
+	"INF": "info",
+	"WRN": "warn",
+	"ERR": "error",
+	"FTL": "fatal",
+	"PNC": "panic",
+}
+
+// ParseLine splits a line written by the file logger, in any format,
+// into its time, level, message and other fields. Lines of another shape
+// are kept as the message.
+func ParseLine(line string) Entry {
+	switch {
+	case strings.HasPrefix(line, "{"):
+		if fields, err := decodeFields([]byte(line)); err == nil {
+			return fieldsEntry(fields)
+		}
+	case strings.HasPrefix(line, zerolog.TimestampFieldName+"=") || strings.HasPrefix(line, zerolog.LevelFieldName+"="):
+		if fields, ok := parseLogfmt(line); ok {
+			return fieldsEntry(fields)
+		}
+	}
+
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return Entry{Message: line}
+	}
+	if _, err := time.Parse(time.RFC3339, ts); err != nil {
+		return Entry{Message: line}
+	}
+	lvl, msg, _ := strings.Cut(rest, " ")
+	level, ok := levelNames[lvl]
+	if !ok {
+		return Entry{Time: ts, Message: rest}
+	}
+	return Entry{Time: ts, Level: level, Message: msg}
+}
+
+func fieldsEntry(fields []jsonField) Entry {
+	var e Entry
+	for _, f := range fields {
+		s, isString := f.value.(string)
+		switch {
+		case f.key == zerolog.TimestampFieldName && isString:
+			e.Time = s
+		case f.key == zerolog.LevelFieldName && isString:
+			e.Level = s
+		case f.key == zerolog.MessageFieldName && isString:
+			e.Message = s
+		default:
+			if e.Fields == nil {
+				e.Fields = make(map[string]any)
+			}
+			e.Fields[f.key] = f.value
+		}
+	}
+	return e
+}
+
+// parseLogfmt parses the key=value pairs of a line written in logfmt.
+func parseLogfmt(line string) ([]jsonField, bool) {
+	var fields []jsonField
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimLeft(line, " ") {
+		k, rest, ok := strings.Cut(line, "=")
+		if !ok || k == "" || strings.Contains(k, " ") {
+			return nil, false
+		}
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			q, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, false
+			}
+			v, _ = strconv.Unquote(q)
+			rest = rest[len(q):]
+		} else {
+			v, rest, _ = strings.Cut(rest, " ")
+		}
+		fields = append(fields, jsonField{k, v})
+		line = rest
+	}
+	return fields, true
+}
+
+// Tail returns the last n lines of the file and its size, from which
+// Follow can continue.
+func Tail(filename string, n int) ([]string, int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// read backwards by chunks until n lines are found
+	const chunk = 4 << 10
+	var buf []byte
+	offset := size
+	for offset > 0 && bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) < n {
+		sz := int64(chunk)
+		if offset < sz {
+			sz = offset
+		}
+		offset -= sz
+		b := make([]byte, sz, int64(len(buf))+sz)
+		if _, err := f.ReadAt(b, offset); err != nil {
+			return nil, 0, err
+		}
+		buf = append(b, buf...)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, size, nil
+}
+
+// Follow calls fn with each line appended to the file after offset,
+// polling it every interval until ctx is done. It starts over from the
+// beginning when the file has been rotated or truncated.
+func Follow(ctx context.Context, filename string, offset int64, interval time.Duration, fn func(line string)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var partial []byte
+	var last os.FileInfo
+	for {
+		info, err := os.Stat(filename)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// rotating
+		case err != nil:
+			return err
+		default:
+			// the size alone misses a new file which has grown past offset
+			// since the last poll
+			if last != nil && !os.SameFile(last, info) || info.Size() < offset {
+				offset, partial = 0, nil
+			}
+			last = info
+			if info.Size() > offset {
+				n, err := readLines(filename, offset, &partial, fn)
+				if err != nil {
+					return err
+				}
+				offset += n
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readLines reads the file from offset and calls fn with the complete
+// lines. An incomplete last line is kept in partial.
+func readLines(filename string, offset int64, partial *[]byte, fn func(line string)) (int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadBytes('\n')
+		n += int64(len(b))
+		*partial = append(*partial, b...)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		fn(strings.TrimRight(string(*partial), "\r\n"))
+		*partial = (*partial)[:0]
+	}
+}
+
+// Files returns the current log file and its backups, the newest first.
+func Files() ([]string, error) {
+	if file == nil {
+		return nil, errors.New("no log file")
+	}
+	items, err := backups(file.filename)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{file.filename}
+	for _, b := range items {
+		files = append(files, b.path)
+	}
+	return files, nil
+}
+
+// Open opens a log file, decompressing the gzipped backups.
+func Open(filename string) (io.ReadCloser, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !isGzip(filename) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFile{zr, f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
@@ -0,0 +1,48 @@
+package zlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollowRotation(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(filename, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, filename, 4, 10*time.Millisecond, func(line string) { lines <- line })
+	}()
+	time.Sleep(30 * time.Millisecond)
+
+	// the new file is already larger than the offset at the next poll
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte("first\nsecond\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("line = %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("line %q not followed", want)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
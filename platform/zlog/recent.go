@@ -0,0 +1,44 @@
+package zlog
+
+import (
+	"bytes"
+	"sync"
+)
+
+// recentLines is the number of lines kept by the ring buffer.
+const recentLines = 500
+
+// ringWriter keeps the last lines written to the log in memory, e.g. for
+// a crash report.
+type ringWriter struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+var recent = &ringWriter{lines: make([]string, recentLines)}
+
+func (r *ringWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		r.lines[r.next] = string(line)
+		r.next = (r.next + 1) % len(r.lines)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// RecentLines returns the last lines written to the log, the oldest
+// first, as they are in the file.
+func RecentLines() []string {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+	if !recent.full {
+		return append([]string{}, recent.lines[:recent.next]...)
+	}
+	return append(append([]string{}, recent.lines[recent.next:]...), recent.lines[:recent.next]...)
+}
@@ -0,0 +1,173 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Redacted replaces the sensitive values in the log.
+const Redacted = "[REDACTED]"
+
+// redaction holds the values hidden from the log.
+type redaction struct {
+	mu       sync.RWMutex
+	patterns []*regexp.Regexp
+	values   []string // the longest first, so a value containing another one is replaced whole
+	home     string
+}
+
+var redact redaction
+
+// AddRedactPattern hides the matches of the regular expression expr in
+// the messages and the fields of the log.
+func AddRedactPattern(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	redact.mu.Lock()
+	redact.patterns = append(redact.patterns, re)
+	redact.mu.Unlock()
+	return nil
+}
+
+// SetRedactPatterns replaces the regular expressions whose matches are
+// hidden from the log, e.g. after the config is reloaded.
+func SetRedactPatterns(exprs []string) error {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, re)
+	}
+	redact.mu.Lock()
+	redact.patterns = patterns
+	redact.mu.Unlock()
+	return nil
+}
+
+// SetRedactValues replaces the known secret values, such as tokens and
+// passwords, hidden from the log. Empty values are ignored.
+func SetRedactValues(values ...string) {
+	var vs []string
+	for _, v := range values {
+		if v != "" {
+			vs = append(vs, v)
+		}
+	}
+	sort.Slice(vs, func(i, j int) bool { return len(vs[i]) > len(vs[j]) })
+	redact.mu.Lock()
+	redact.values = vs
+	redact.mu.Unlock()
+}
+
+// RedactHome replaces the home directory of the user with ~ in the log
+// when enabled.
+func RedactHome(enabled bool) {
+	home := ""
+	if enabled {
+		home, _ = os.UserHomeDir()
+	}
+	redact.mu.Lock()
+	redact.home = home
+	redact.mu.Unlock()
+}
+
+func (r *redaction) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.patterns) == 0 && len(r.values) == 0 && r.home == ""
+}
+
+// Redact returns s with the registered patterns, values and home
+// directory hidden.
+func Redact(s string) string {
+	redact.mu.RLock()
+	defer redact.mu.RUnlock()
+	for _, v := range redact.values {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	for _, re := range redact.patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	if redact.home != "" {
+		s = strings.ReplaceAll(s, redact.home, "~")
+	}
+	return s
+}
+
+// redactValue redacts the strings of a decoded JSON value.
+func redactValue(v any) any {
+	switch x := v.(type) {
+	case string:
+		return Redact(x)
+	case []any:
+		for i := range x {
+			x[i] = redactValue(x[i])
+		}
+	case map[string]any:
+		for k := range x {
+			x[k] = redactValue(x[k])
+		}
+	}
+	return v
+}
+
+// redactWriter redacts the messages and the fields of the JSON lines of
+// zerolog before they reach w.
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r redactWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if redact.empty() {
+		return writeLevel(r.w, level, p)
+	}
+	fields, err := decodeFields(p)
+	if err != nil {
+		// not a JSON line, redact it as text
+		if _, err := writeLevel(r.w, level, []byte(Redact(string(p)))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(f.key); err != nil {
+			return 0, err
+		}
+		buf.Truncate(buf.Len() - 1) // the newline of Encode
+		buf.WriteByte(':')
+		if err := enc.Encode(redactValue(f.value)); err != nil {
+			return 0, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+
+	if _, err := writeLevel(r.w, level, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
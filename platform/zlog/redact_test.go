@@ -0,0 +1,121 @@
+package zlog
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// resetRedaction clears the redaction after the test.
+func resetRedaction(t *testing.T) {
+	t.Cleanup(func() {
+		redact.mu.Lock()
+		redact.patterns, redact.values, redact.home = nil, nil, ""
+		redact.mu.Unlock()
+	})
+}
+
+func TestRedact(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "alice")
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		name     string
+		values   []string
+		patterns []string
+		home     bool
+		in, want string
+	}{
+		{
+			name: "nothing registered",
+			in:   "token abc123",
+			want: "token abc123",
+		},
+		{
+			name:   "values",
+			values: []string{"abc123", "", "hunter2"},
+			in:     "token abc123, password hunter2, again abc123",
+			want:   "token [REDACTED], password [REDACTED], again [REDACTED]",
+		},
+		{
+			name:   "longest value first",
+			values: []string{"abc", "abcdef"},
+			in:     "abcdef",
+			want:   "[REDACTED]",
+		},
+		{
+			name:     "patterns",
+			patterns: []string{`Bearer \S+`, `\d{4}-\d{4}-\d{4}-\d{4}`},
+			in:       "Authorization: Bearer xyz card 1234-5678-9012-3456",
+			want:     "Authorization: [REDACTED] card [REDACTED]",
+		},
+		{
+			name: "home",
+			home: true,
+			in:   "open " + filepath.Join(home, "secret.txt"),
+			want: "open " + filepath.Join("~", "secret.txt"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRedaction(t)
+			SetRedactValues(tt.values...)
+			if err := SetRedactPatterns(tt.patterns); err != nil {
+				t.Fatal(err)
+			}
+			RedactHome(tt.home)
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactPatternError(t *testing.T) {
+	resetRedaction(t)
+	if err := AddRedactPattern("("); err == nil {
+		t.Error("AddRedactPattern: want an error")
+	}
+	if err := SetRedactPatterns([]string{"ok", "["}); err == nil {
+		t.Error("SetRedactPatterns: want an error")
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	resetRedaction(t)
+	SetRedactValues("s3cret")
+	if err := AddRedactPattern(`token=\w+`); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	l := zerolog.New(redactWriter{&buf})
+	l.Info().
+		Str("cmd", "login -p s3cret").
+		Strs("args", []string{"-p", "s3cret"}).
+		Interface("nested", map[string]any{"url": "https://x/?token=abc"}).
+		Int("n", 1).
+		Msg("run <s3cret>")
+
+	want := `{"level":"info","cmd":"login -p [REDACTED]","args":["-p","[REDACTED]"],"nested":{"url":"https://x/?[REDACTED]"},"n":1,"message":"run <[REDACTED]>"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedactWriterUnchanged(t *testing.T) {
+	resetRedaction(t)
+
+	var buf bytes.Buffer
+	line := []byte(`{"level":"info","x":1.50,"message":"a"}` + "\n")
+	if _, err := (redactWriter{&buf}).Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), line) {
+		t.Errorf("got %s, want the line unchanged", buf.String())
+	}
+}
@@ -1,34 +1,110 @@
 package zlog
 
 import (
+	"compress/gzip"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
+// RotateOptions configures the rotation of the log file. Zero values
+// disable the corresponding limit.
+type RotateOptions struct {
+	MaxSize    int64         // rotate when the file reaches this size in bytes
+	Daily      bool          // rotate when the day changes
+	MaxBackups int           // number of backups kept
+	Timestamp  bool          // name backups log.2006-01-02T15-04-05.000 instead of log.1, log.2, ...
+	Compress   bool          // gzip the backups in the background
+	MaxAge     time.Duration // remove the backups older than this
+	MaxTotal   int64         // remove the oldest backups while the log and its backups exceed this size
+}
+
+// backupTime is the layout of the timestamp of the backups. It has no
+// colons, which Windows doesn't allow in file names.
+const backupTime = "2006-01-02T15-04-05.000"
+
+// rotateRetry is the delay before a failed rotation is retried.
+const rotateRetry = 10 * time.Second
+
 type rotateFile struct {
-	mu sync.Mutex
-	f  *os.File
+	mu     sync.Mutex
+	f      *os.File
+	size   int64     // tracked on write instead of a Stat each time
+	opened time.Time // the day of the content, for the daily rotation
+	retry  time.Time // when to retry a failed rotation
 
 	filename string
-	maxsize  int64
+	opts     RotateOptions
+
+	// maintain is signaled after a rotation, so the backups are
+	// compressed, renamed and removed in the background
+	maintain chan struct{}
+}
+
+func newRotateFile(filename string, opts RotateOptions) *rotateFile {
+	f := &rotateFile{
+		filename: filename,
+		opts:     opts,
+		maintain: make(chan struct{}, 1),
+	}
+	go f.maintainer()
+	return f
 }
 
 func (f *rotateFile) Write(b []byte) (int, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.f == nil {
+		if err := f.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	if f.opts.Daily && !sameDay(f.opened, time.Now()) {
+		f.tryRotate()
+	}
 	n, err := f.f.Write(b)
+	f.size += int64(n)
 	if err != nil {
 		return n, err
 	}
-	sz, err := f.size()
-	if err != nil {
-		return n, err
+	if f.opts.MaxSize > 0 && f.size >= f.opts.MaxSize {
+		f.tryRotate()
+	}
+	return n, nil
+}
+
+// tryRotate rotates the file, unless a failed rotation waits to be
+// retried. The entries keep going to the current file meanwhile.
+func (f *rotateFile) tryRotate() {
+	if time.Now().Before(f.retry) {
+		return
 	}
-	if sz >= f.maxsize {
-		err = f.rotate()
+	if err := f.rotate(); err != nil {
+		f.retry = time.Now().Add(rotateRetry)
+		// the logger itself can't be used here
+		fmt.Fprintln(os.Stderr, "zlog:", err)
 	}
-	return n, err
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// setOptions changes the options, e.g. after the config is reloaded.
+func (f *rotateFile) setOptions(opts RotateOptions) {
+	f.mu.Lock()
+	f.opts = opts
+	f.mu.Unlock()
+	f.signal()
 }
 
 func (f *rotateFile) open() error {
@@ -37,6 +113,13 @@ func (f *rotateFile) open() error {
 		return err
 	}
 	f.f = file
+	f.size = 0
+	f.opened = time.Now()
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		f.size = info.Size()
+		f.opened = info.ModTime()
+	}
+	f.signal()
 	return nil
 }
 
@@ -47,25 +130,236 @@ func (f *rotateFile) close() error {
 	return nil
 }
 
-func (f *rotateFile) size() (int64, error) {
-	if f.f == nil {
-		return 0, errors.New("not open")
-	}
-	fi, err := f.f.Stat()
-	if err != nil {
-		return 0, err
-	}
-	return fi.Size(), nil
-}
-
+// rotate renames the file to a timestamped backup and opens a new one.
+// The maintainer renumbers the backups if they are numbered.
 func (f *rotateFile) rotate() error {
 	_ = f.close()
-	if err := os.Rename(f.filename, f.filename+".1"); err != nil {
-		return err
+	name := f.filename + "." + time.Now().Format(backupTime)
+	for i := 1; exists(name); i++ {
+		name = f.filename + "." + time.Now().Format(backupTime) + "-" + strconv.Itoa(i)
+	}
+	if err := os.Rename(f.filename, name); err != nil {
+		// e.g. another process has the file open on Windows
+		return errors.Join(err, f.reopen())
 	}
 	var err error
 	if f.f, err = os.Create(f.filename); err != nil {
+		return errors.Join(err, f.reopen())
+	}
+	f.size = 0
+	f.opened = time.Now()
+	f.retry = time.Time{}
+	f.signal()
+	return nil
+}
+
+// reopen opens the file for append again after a failed rotation,
+// keeping the day of its content.
+func (f *rotateFile) reopen() error {
+	file, err := os.OpenFile(f.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		f.f = nil
 		return err
 	}
+	f.f = file
+	if info, err := file.Stat(); err == nil {
+		f.size = info.Size()
+	}
 	return nil
 }
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (f *rotateFile) signal() {
+	select {
+	case f.maintain <- struct{}{}:
+	default:
+	}
+}
+
+func (f *rotateFile) maintainer() {
+	for range f.maintain {
+		f.mu.Lock()
+		opts := f.opts
+		f.mu.Unlock()
+		if err := maintainBackups(f.filename, opts); err != nil {
+			// the logger itself can't be used here
+			fmt.Fprintln(os.Stderr, "zlog:", err)
+		}
+	}
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// backups returns the backups of filename, the newest first.
+func backups(filename string) ([]backup, error) {
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(filename) + "."
+
+	var items []backup
+	for _, e := range entries {
+		name := e.Name()
+		// the backups are numbered or timestamped
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || name[len(prefix)] < '0' || name[len(prefix)] > '9' {
+			continue
+		}
+		p := filepath.Join(filepath.Dir(filename), name)
+		if strings.HasSuffix(name, ".tmp") {
+			// left by an interrupted compression
+			_ = os.Remove(p)
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		items = append(items, backup{path: p, modTime: info.ModTime(), size: info.Size()})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].modTime.Equal(items[j].modTime) {
+			return items[i].modTime.After(items[j].modTime)
+		}
+		return items[i].path > items[j].path
+	})
+	return items, nil
+}
+
+// maintainBackups compresses, removes and renames the backups of
+// filename as opts says.
+func maintainBackups(filename string, opts RotateOptions) error {
+	items, err := backups(filename)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if opts.Compress {
+		for i := range items {
+			if isGzip(items[i].path) {
+				continue
+			}
+			gz, size, err := compress(items[i].path, items[i].modTime)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items[i].path, items[i].size = gz, size
+		}
+	}
+
+	var total int64
+	if info, err := os.Stat(filename); err == nil {
+		total = info.Size()
+	}
+	keep := items[:0]
+	for i, b := range items {
+		total += b.size
+		switch {
+		case opts.MaxBackups > 0 && i >= opts.MaxBackups,
+			opts.MaxAge > 0 && time.Since(b.modTime) > opts.MaxAge,
+			opts.MaxTotal > 0 && total > opts.MaxTotal:
+			if err := os.Remove(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			keep = append(keep, b)
+		}
+	}
+
+	if !opts.Timestamp {
+		errs = append(errs, renumber(filename, keep))
+	}
+	return errors.Join(errs...)
+}
+
+// compress gzips the file name into name.gz, keeping its modification
+// time, and removes it.
+func compress(name string, modTime time.Time) (string, int64, error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	target := name + ".gz"
+	dst, err := os.OpenFile(target+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(target + ".tmp")
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return "", 0, err
+	}
+	info, err := dst.Stat()
+	if err != nil {
+		dst.Close()
+		return "", 0, err
+	}
+	if err := dst.Close(); err != nil {
+		return "", 0, err
+	}
+	_ = os.Chtimes(target+".tmp", modTime, modTime)
+	if err := os.Rename(target+".tmp", target); err != nil {
+		return "", 0, err
+	}
+	src.Close()
+	return target, info.Size(), os.Remove(name)
+}
+
+// renumber renames the backups, the newest first, to filename.1,
+// filename.2, ... through temporary names, so none is overwritten.
+func renumber(filename string, items []backup) error {
+	targets := make([]string, len(items))
+	for i := range items {
+		targets[i] = filename + "." + strconv.Itoa(i+1)
+		if isGzip(items[i].path) {
+			targets[i] += ".gz"
+		}
+	}
+
+	var errs []error
+	moved := make([]bool, len(items))
+	for i := range items {
+		if items[i].path == targets[i] {
+			continue
+		}
+		if err := os.Rename(items[i].path, targets[i]+"~"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		moved[i] = true
+	}
+	for i := range items {
+		if !moved[i] {
+			continue
+		}
+		if err := os.Rename(targets[i]+"~", targets[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isGzip reports whether the backup p is compressed. p may be left with
+// the temporary name of renumber.
+func isGzip(p string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(p, "~"), ".gz")
+}
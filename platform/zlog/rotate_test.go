@@ -0,0 +1,56 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateRenameFailure(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "log")
+	f := newRotateFile(filename, RotateOptions{MaxSize: 10})
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+
+	// the rename fails as the file is gone, like a file held open
+	// elsewhere on Windows
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"0123456789\n", "after\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q): %v", line, err)
+		}
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "after\n" {
+		t.Errorf("log = %q, want %q", got, "after\n")
+	}
+	if f.retry.IsZero() {
+		t.Error("the failed rotation is not retried later")
+	}
+
+	// the rotation succeeds once retried
+	f.retry = time.Time{}
+	if _, err := f.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	items, err := backups(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("backups = %d, want 1", len(items))
+	}
+	b, _ = os.ReadFile(items[0].path)
+	if !strings.HasSuffix(string(b), "next\n") {
+		t.Errorf("backup = %q, want it to end with the last entry", b)
+	}
+}
@@ -0,0 +1,219 @@
+package zlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SampleOptions limits the entries of loops failing repeatedly. Zero
+// values disable the corresponding limit.
+type SampleOptions struct {
+	// Window collapses the identical messages of a component and a level
+	// logged within it: the first is written and the others are counted
+	// in an entry "message (repeated N times)" when the window ends.
+	Window time.Duration
+	// Rates is the maximum number of entries per second by level name,
+	// e.g. {"debug": 100}. The entries beyond are dropped and counted.
+	Rates map[string]int
+}
+
+// maxRepeats bounds the messages tracked within a window.
+const maxRepeats = 1000
+
+type repeatKey struct {
+	component string
+	level     zerolog.Level
+	message   string
+}
+
+type repeat struct {
+	first time.Time
+	count int
+}
+
+// summary is the count of a message repeated within a window.
+type summary struct {
+	repeatKey
+	count int
+}
+
+// rateBucket is a token bucket of rate tokens per second, up to rate.
+type rateBucket struct {
+	rate    float64
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// sampler deduplicates and rate limits the entries after the levels of
+// the components have filtered them.
+type sampler struct {
+	mu      sync.Mutex
+	window  time.Duration
+	rates   map[zerolog.Level]*rateBucket
+	repeats map[repeatKey]*repeat
+	stop    chan struct{}
+}
+
+var sampling = &sampler{}
+
+// SetSampling changes the deduplication and the rate limits, e.g. after
+// the config is reloaded.
+func SetSampling(opts SampleOptions) error {
+	rates := make(map[zerolog.Level]*rateBucket, len(opts.Rates))
+	var errs []string
+	for name, rate := range opts.Rates {
+		l, err := parseLevel(name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if rate > 0 {
+			rates[l] = &rateBucket{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+		}
+	}
+
+	sampling.flush(true)
+	sampling.mu.Lock()
+	sampling.window = opts.Window
+	sampling.rates = rates
+	sampling.repeats = make(map[repeatKey]*repeat)
+	enabled := opts.Window > 0 || len(rates) > 0
+	if enabled && sampling.stop == nil {
+		sampling.stop = make(chan struct{})
+		go sampling.run(sampling.stop)
+	} else if !enabled && sampling.stop != nil {
+		close(sampling.stop)
+		sampling.stop = nil
+	}
+	sampling.mu.Unlock()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("log rates: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// hook returns the zerolog hook sampling the entries of component.
+func (s *sampler) hook(component string) zerolog.Hook {
+	return zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		if !e.Enabled() {
+			return
+		}
+		ok, expired := s.allow(component, level, msg, time.Now())
+		if expired.count > 0 {
+			// before the entry, which starts a new window
+			writeSummaries([]summary{expired}, nil)
+		}
+		if !ok {
+			e.Discard()
+		}
+	})
+}
+
+// allow reports whether the entry is written. It returns the count of
+// the previous window of the message if it has ended and run hasn't
+// written it yet.
+func (s *sampler) allow(component string, level zerolog.Level, msg string, now time.Time) (bool, summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired summary
+	if s.window > 0 {
+		k := repeatKey{component, level, msg}
+		r, ok := s.repeats[k]
+		switch {
+		case ok && now.Sub(r.first) < s.window:
+			r.count++
+			return false, expired
+		case ok:
+			expired = summary{k, r.count}
+			s.repeats[k] = &repeat{first: now}
+		case len(s.repeats) < maxRepeats:
+			s.repeats[k] = &repeat{first: now}
+		}
+		// beyond maxRepeats, the new messages are not tracked until run
+		// removes the windows which have ended
+	}
+	return s.take(level, now), expired
+}
+
+func (s *sampler) take(level zerolog.Level, now time.Time) bool {
+	b, ok := s.rates[level]
+	if !ok {
+		return true
+	}
+	b.tokens = min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		b.dropped++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (s *sampler) run(stop chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.flush(false)
+		}
+	}
+}
+
+// flush writes the counts of the windows which have ended, or of all of
+// them, and of the entries dropped by the rate limits.
+func (s *sampler) flush(all bool) {
+	var repeated []summary
+	dropped := make(map[zerolog.Level]int)
+
+	now := time.Now()
+	s.mu.Lock()
+	for k, r := range s.repeats {
+		if !all && now.Sub(r.first) < s.window {
+			continue
+		}
+		if r.count > 0 {
+			repeated = append(repeated, summary{k, r.count})
+		}
+		delete(s.repeats, k)
+	}
+	for l, b := range s.rates {
+		if b.dropped > 0 {
+			dropped[l] = b.dropped
+			b.dropped = 0
+		}
+	}
+	s.mu.Unlock()
+
+	writeSummaries(repeated, dropped)
+}
+
+// writeSummaries writes the counts of the repeated messages and of the
+// entries dropped by level. They are written through base, so they are
+// neither filtered nor sampled again.
+func writeSummaries(repeated []summary, dropped map[zerolog.Level]int) {
+	for _, r := range repeated {
+		l := base
+		if r.component != "" {
+			l = base.With().Str("component", r.component).Logger()
+		}
+		l.WithLevel(r.level).Timestamp().Int("repeated", r.count).
+			Msg(fmt.Sprintf("%s (repeated %d times)", r.message, r.count))
+	}
+	for level, n := range dropped {
+		base.Warn().Timestamp().Str("dropped_level", level.String()).Int("dropped", n).
+			Msg(fmt.Sprintf("%d %s entries dropped by the rate limit", n, level))
+	}
+}
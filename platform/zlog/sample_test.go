@@ -0,0 +1,156 @@
+package zlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// captureBase makes base write to the returned buffer for the test.
+func captureBase(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	saved := base
+	base = zerolog.New(buf)
+	t.Cleanup(func() { base = saved })
+	return buf
+}
+
+func newTestSampler(window time.Duration, rates map[zerolog.Level]int) *sampler {
+	s := &sampler{window: window, repeats: make(map[repeatKey]*repeat), rates: make(map[zerolog.Level]*rateBucket)}
+	for l, r := range rates {
+		s.rates[l] = &rateBucket{rate: float64(r), tokens: float64(r)}
+	}
+	return s
+}
+
+func lines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestSamplerDedup(t *testing.T) {
+	buf := captureBase(t)
+	s := newTestSampler(time.Second, nil)
+	t0 := time.Now()
+
+	for i := 0; i < 10; i++ {
+		ok, expired := s.allow("exec", zerolog.ErrorLevel, "failed", t0.Add(time.Duration(i)*50*time.Millisecond))
+		if ok != (i == 0) {
+			t.Errorf("allow #%d = %t", i, ok)
+		}
+		if expired.count != 0 {
+			t.Errorf("allow #%d: expired count %d", i, expired.count)
+		}
+	}
+	// another component, level or message is not a repeat
+	for _, k := range []repeatKey{{"", zerolog.ErrorLevel, "failed"}, {"exec", zerolog.WarnLevel, "failed"}, {"exec", zerolog.ErrorLevel, "other"}} {
+		if ok, _ := s.allow(k.component, k.level, k.message, t0); !ok {
+			t.Errorf("allow(%v) = false", k)
+		}
+	}
+
+	s.flush(true)
+	got := lines(buf)
+	if len(got) != 1 {
+		t.Fatalf("flush wrote %d entries, want 1: %q", len(got), got)
+	}
+	want := `{"level":"error","component":"exec","repeated":9,"message":"failed (repeated 9 times)"}`
+	if g := withoutTime(got[0]); g != want {
+		t.Errorf("got  %s\nwant %s", g, want)
+	}
+}
+
+// withoutTime removes the time field of a JSON line.
+func withoutTime(line string) string {
+	before, rest, ok := strings.Cut(line, `"time":`)
+	if !ok {
+		return line
+	}
+	_, after, _ := strings.Cut(rest, ",")
+	return before + after
+}
+
+func TestSamplerWindowExpiry(t *testing.T) {
+	buf := captureBase(t)
+	s := newTestSampler(time.Second, nil)
+	t0 := time.Now()
+
+	s.allow("", zerolog.ErrorLevel, "failed", t0)
+	for i := 1; i <= 9; i++ {
+		s.allow("", zerolog.ErrorLevel, "failed", t0.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	// after the window, before run has flushed it
+	ok, expired := s.allow("", zerolog.ErrorLevel, "failed", t0.Add(1050*time.Millisecond))
+	if !ok {
+		t.Error("the first entry of a new window is dropped")
+	}
+	if expired.count != 9 {
+		t.Errorf("expired count = %d, want 9", expired.count)
+	}
+	s.flush(true)
+	if buf.Len() != 0 {
+		t.Errorf("the new window has no repeats, got %s", buf.String())
+	}
+}
+
+func TestSamplerHookWritesExpiredWindow(t *testing.T) {
+	buf := captureBase(t)
+	s := newTestSampler(20*time.Millisecond, nil)
+	l := base.Hook(s.hook(""))
+
+	l.Info().Msg("tick")
+	l.Info().Msg("tick")
+	l.Info().Msg("tick")
+	time.Sleep(30 * time.Millisecond)
+	l.Info().Msg("tick")
+
+	got := lines(buf)
+	want := []string{
+		`{"level":"info","message":"tick"}`,
+		`{"level":"info","repeated":2,"message":"tick (repeated 2 times)"}`,
+		`{"level":"info","message":"tick"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if g := withoutTime(got[i]); g != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, g, want[i])
+		}
+	}
+}
+
+func TestSamplerRates(t *testing.T) {
+	buf := captureBase(t)
+	s := newTestSampler(0, map[zerolog.Level]int{zerolog.DebugLevel: 2})
+	t0 := time.Now()
+	for _, b := range s.rates {
+		b.last = t0
+	}
+
+	tests := []struct {
+		level zerolog.Level
+		at    time.Duration
+		want  bool
+	}{
+		{zerolog.DebugLevel, 0, true},
+		{zerolog.DebugLevel, 0, true},
+		{zerolog.DebugLevel, 0, false},
+		{zerolog.InfoLevel, 0, true}, // no limit
+		{zerolog.DebugLevel, 100 * time.Millisecond, false},
+		{zerolog.DebugLevel, 500 * time.Millisecond, true}, // one token back
+		{zerolog.DebugLevel, 500 * time.Millisecond, false},
+	}
+	for i, tt := range tests {
+		if ok, _ := s.allow("", tt.level, "m", t0.Add(tt.at)); ok != tt.want {
+			t.Errorf("#%d: allow(%s, +%s) = %t, want %t", i, tt.level, tt.at, ok, tt.want)
+		}
+	}
+
+	s.flush(true)
+	if got := buf.String(); !strings.Contains(got, `"message":"3 debug entries dropped by the rate limit"`) {
+		t.Errorf("got %s, want the count of the dropped entries", got)
+	}
+}
@@ -0,0 +1,64 @@
+package zlog
+
+import (
+	"errors"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// SinkOptions configures an output of the logger besides the file.
+type SinkOptions struct {
+	Level  string // minimum level written to the sink, on top of the levels of the components
+	Format string // console (default), json or logfmt; ignored by journald
+	Color  bool   // colorize the console format
+}
+
+// sink is an output with its own level.
+type sink struct {
+	level zerolog.Level
+	w     io.Writer
+}
+
+func newSink(w io.Writer, level string) (sink, error) {
+	l, err := parseLevel(level)
+	if err != nil {
+		return sink{}, err
+	}
+	if level == "" {
+		l = zerolog.TraceLevel
+	}
+	return sink{level: l, w: w}, nil
+}
+
+// multiSink writes each JSON line of zerolog to the sinks whose level it
+// reaches. Lines without a level go to all of them.
+type multiSink []sink
+
+func (m multiSink) Write(p []byte) (int, error) {
+	return m.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (m multiSink) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var errs []error
+	for _, s := range m {
+		if level < s.level {
+			continue
+		}
+		if _, err := writeLevel(s.w, level, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeLevel writes p to w, with its level if w is a zerolog.LevelWriter.
+func writeLevel(w io.Writer, level zerolog.Level, p []byte) (int, error) {
+	if lw, ok := w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
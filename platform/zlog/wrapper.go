@@ -0,0 +1,91 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// With returns a context of the logger to add fields to, e.g.
+//
+//	log := zlog.With().Str("file", name).Logger()
+//	log.Info().Int("size", n).Msg("loaded")
+//
+// The logger should be created after SetFileLogger.
+func With() zerolog.Context {
+	return Logger.With().Timestamp()
+}
+
+// withError adds the first error of args to e as the error field, and
+// its stack trace, if any, as the stack field.
+func withError(e *zerolog.Event, args []any) *zerolog.Event {
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return e.Stack().Err(err)
+		}
+	}
+	return e
+}
+
+func Trace(args ...any) {
+	withError(Logger.Trace(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Tracef(format string, args ...any) {
+	withError(Logger.Trace(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func Debug(args ...any) {
+	withError(Logger.Debug(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Debugf(format string, args ...any) {
+	withError(Logger.Debug(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func Info(args ...any) {
+	withError(Logger.Info(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Infof(format string, args ...any) {
+	withError(Logger.Info(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func Warn(args ...any) {
+	withError(Logger.Warn(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Warnf(format string, args ...any) {
+	withError(Logger.Warn(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func Error(args ...any) {
+	withError(Logger.Error(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Errorf(format string, args ...any) {
+	withError(Logger.Error(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+func Panic(args ...any) {
+	withError(Logger.Panic(), args).Timestamp().Msg(fmt.Sprint(args...))
+}
+
+func Panicf(format string, args ...any) {
+	withError(Logger.Panic(), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+}
+
+// Fatal logs args and exits once the entry is written. zerolog's Fatal
+// would exit with the entry still queued by the background writer.
+func Fatal(args ...any) {
+	withError(Logger.WithLevel(zerolog.FatalLevel), args).Timestamp().Msg(fmt.Sprint(args...))
+	Close()
+	os.Exit(1)
+}
+
+func Fatalf(format string, args ...any) {
+	withError(Logger.WithLevel(zerolog.FatalLevel), args).Timestamp().Msg(fmt.Sprintf(format, args...))
+	Close()
+	os.Exit(1)
+}
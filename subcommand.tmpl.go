@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"{{.ProjectName}}/config"
+	"{{.ProjectName}}/features"
+	"{{.ProjectName}}/platform/zlog"
+)
+
+// subcommands are headless tasks run instead of the app, e.g.
+// "app hash photo.jpg". They write JSON to stdout.
+var subcommands = map[string]func(ctx context.Context, args []string) error{
+	"hash":   hashCommand,
+	"list":   listCommand,
+	"config": configCommand,
+	"logs":   logsCommand,
+}
+
+// runSubcommand runs the subcommand named by args[0], if any.
+func runSubcommand(ctx context.Context, args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return 0, false
+	}
+
+	var ferr *config.FlagError
+	switch err := cmd(ctx, args[1:]); {
+	case err == nil:
+		return 0, true
+	case errors.As(err, &ferr):
+		if errors.Is(ferr, flag.ErrHelp) {
+			return 0, true
+		}
+		return 2, true
+	default:
+		return fail(err), true
+	}
+}
+
+// subcommandFlags returns the flag set of a subcommand, named by the
+// first word of usage.
+func subcommandFlags(usage string) *flag.FlagSet {
+	name, _, _ := strings.Cut(usage, " ")
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n", filepath.Base(os.Args[0]), usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of the subcommand and checks that n
+// positional arguments remain.
+func parseArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		return &config.FlagError{Err: err}
+	}
+	if fs.NArg() != n {
+		err := fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), n, fs.NArg())
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return &config.FlagError{Err: err}
+	}
+	return nil
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func hashCommand(ctx context.Context, args []string) error {
+	fs := subcommandFlags("hash <file>")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	filename := fs.Arg(0)
+	sum, err := (&features.Calc{}).MD5File(filename)
+	if err != nil {
+		return err
+	}
+	return writeJSON(struct {
+		File string `json:"file"`
+		MD5  string `json:"md5"`
+	}{filename, sum})
+}
+
+func listCommand(ctx context.Context, args []string) error {
+	fs := subcommandFlags("list <dir>")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	dir := fs.Arg(0)
+	images, err := features.AllImages(dir)
+	if err != nil {
+		return err
+	}
+	if images == nil {
+		images = []string{}
+	}
+	return writeJSON(struct {
+		Dir    string   `json:"dir"`
+		Images []string `json:"images"`
+	}{dir, images})
+}
+
+func configCommand(ctx context.Context, args []string) error {
+	// the flags of the configuration, e.g. -profile, apply as usual
+	fs := subcommandFlags("config print [flags]")
+	if len(args) == 0 || args[0] != "print" {
+		fs.Usage()
+		return &config.FlagError{Err: errors.New("config: unknown command")}
+	}
+	if err := config.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+	return writeJSON(config.Effective())
+}
+
+func logsCommand(ctx context.Context, args []string) error {
+	fs := subcommandFlags("logs tail [-n lines] [-f]")
+	n := fs.Int("n", 20, "Number of lines")
+	follow := fs.Bool("f", false, "Keep printing the lines appended to the log")
+	if len(args) == 0 || args[0] != "tail" {
+		fs.Usage()
+		return &config.FlagError{Err: errors.New("logs: unknown command")}
+	}
+	if err := parseArgs(fs, args[1:], 0); err != nil {
+		return err
+	}
+
+	// one JSON entry per line, so the output can be streamed
+	enc := json.NewEncoder(os.Stdout)
+	lines, offset, err := zlog.Tail(logFile, *n)
+	if err != nil && !(*follow && errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+	for _, line := range lines {
+		if err := enc.Encode(zlog.ParseLine(line)); err != nil {
+			return err
+		}
+	}
+	if !*follow {
+		return nil
+	}
+	return zlog.Follow(ctx, logFile, offset, 500*time.Millisecond, func(line string) {
+		_ = enc.Encode(zlog.ParseLine(line))
+	})
+}